@@ -0,0 +1,199 @@
+package confection
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// RegisterAlias makes the @type name alias resolve to the factories and
+// conversions registered under target, so a type can be renamed without
+// breaking documents that use the old name. Aliases are shared by every
+// Interface in the registry. Combine with Deprecate to warn about the old name.
+// Pass nil for c to use the global registry.
+// Panics if alias is already an alias or has a factory, or if c is sealed.
+func RegisterAlias(c *Confection, alias, target string) {
+	if err := TryRegisterAlias(c, alias, target); err != nil {
+		panic(err)
+	}
+}
+
+// TryRegisterAlias is like RegisterAlias but returns an error wrapping
+// ErrDuplicateType or ErrSealed instead of panicking.
+func TryRegisterAlias(c *Confection, alias, target string) error {
+	conf := getConfection(c)
+
+	unlock := conf.lock()
+	defer unlock()
+
+	if conf.sealed {
+		return fmt.Errorf("unable to register alias %q for %q: %w", alias, target, ErrSealed)
+	}
+	if conf.aliasTarget(target) == alias {
+		return fmt.Errorf("unable to register alias %q: it is an alias of itself: %w", alias, ErrDuplicateType)
+	}
+	if _, exists := conf.aliases[alias]; exists {
+		return fmt.Errorf("unable to register alias %q for %q: %w", alias, target, ErrDuplicateType)
+	}
+	for name := range conf.interfaceTypes() {
+		if conf.factory(name, alias) != nil {
+			return fmt.Errorf("unable to register alias %q for %q: Interface %q has a factory for it: %w", alias, target, name, ErrDuplicateType)
+		}
+	}
+
+	conf.aliases[alias] = target
+
+	return nil
+}
+
+// Deprecation describes why a @type name should no longer be used.
+type Deprecation struct {
+	// Message explains the deprecation.
+	Message string
+	// Replacement is the @type name to use instead, if any. It defaults to
+	// the target of a deprecated alias.
+	Replacement string
+}
+
+// Deprecate marks the @type name typeName, which may be an alias, as
+// deprecated. Make and Validate still accept it but report a Warning to the
+// registry's WarningHandler each time it is used. Deprecating an alias's
+// target deprecates the alias too. Pass nil for c to use the global registry.
+// Panics with ErrSealed if c is sealed.
+func Deprecate(c *Confection, typeName string, d Deprecation) {
+	if err := TryDeprecate(c, typeName, d); err != nil {
+		panic(err)
+	}
+}
+
+// TryDeprecate is like Deprecate but returns an error wrapping ErrSealed
+// instead of panicking.
+func TryDeprecate(c *Confection, typeName string, d Deprecation) error {
+	conf := getConfection(c)
+
+	unlock := conf.lock()
+	defer unlock()
+
+	if conf.sealed {
+		return fmt.Errorf("unable to deprecate %q: %w", typeName, ErrSealed)
+	}
+	conf.deprecations[typeName] = d
+
+	return nil
+}
+
+// deprecation returns the deprecation of typeName, or of target, the name it
+// resolves to, if either is deprecated. The caller must hold c.rlock.
+func (c *Confection) deprecation(typeName, target string) *Deprecation {
+	d, ok := c.deprecationOf(typeName)
+	if !ok {
+		if d, ok = c.deprecationOf(target); !ok {
+			return nil
+		}
+	}
+	if d.Replacement == "" && typeName != target {
+		d.Replacement = target
+	}
+	return &d
+}
+
+// deprecated returns the deprecation of the @type name typeName, if any.
+func (c *Confection) deprecated(typeName string) *Deprecation {
+	unlock := c.rlock()
+	defer unlock()
+
+	return c.deprecation(typeName, c.aliasTarget(typeName))
+}
+
+// aliasedTypes describes the aliases that resolve to a factory for the named
+// Interface, with the Configuration type their configs are decoded into,
+// sorted by name.
+func (c *Confection) aliasedTypes(interfaceName string) []FactoryInfo {
+	unlock := c.rlock()
+	defer unlock()
+
+	var infos []FactoryInfo
+	for _, alias := range c.names(func(r *Confection) []string { return slices.Collect(maps.Keys(r.aliases)) }) {
+		chain, reg := c.conversionChain(interfaceName, c.aliasTarget(alias))
+		if reg == nil {
+			continue
+		}
+		info := reg.info(interfaceName, alias)
+		if len(chain) > 0 {
+			info.Config = chain[0].fromType
+		}
+		infos = append(infos, info)
+	}
+	slices.SortFunc(infos, func(a, b FactoryInfo) int {
+		return strings.Compare(a.Type, b.Type)
+	})
+	return infos
+}
+
+// Warning reports the use of a deprecated @type name. Warnings do not stop
+// Make or Validate.
+type Warning struct {
+	// Line and Column locate the TypedConfig's typed_config block.
+	Line   int
+	Column int
+	// Interface is the name of the Interface being constructed.
+	Interface string
+	// Type is the deprecated @type name as written in the config.
+	Type string
+	// Name is the name of the TypedConfig, if any.
+	Name string
+	// Message and Replacement are copied from the Deprecation.
+	Message     string
+	Replacement string
+}
+
+func (w Warning) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "line %d", w.Line)
+	if w.Name != "" {
+		fmt.Fprintf(&b, ": name %q", w.Name)
+	}
+	fmt.Fprintf(&b, ": interface %s: @type %q is deprecated", w.Interface, w.Type)
+	if w.Message != "" {
+		fmt.Fprintf(&b, ": %s", w.Message)
+	}
+	if w.Replacement != "" {
+		fmt.Fprintf(&b, "; use %q instead", w.Replacement)
+	}
+	return b.String()
+}
+
+// WarningHandler receives the Warnings reported by Make and Validate.
+// It may be called from several goroutines at once.
+type WarningHandler func(Warning)
+
+// WithWarningHandler sends Warnings to h instead of the standard logger.
+// A nil h discards them.
+func WithWarningHandler(h WarningHandler) Option {
+	return func(c *Confection) {
+		if h == nil {
+			h = func(Warning) {}
+		}
+		c.warn = h
+	}
+}
+
+func logWarning(w Warning) {
+	log.Printf("confection: %s", w)
+}
+
+// warning returns a Warning for tc's use of a deprecated @type.
+func (tc *TypedConfig) warning(interfaceName string, d Deprecation) Warning {
+	line, column := tc.position()
+	return Warning{
+		Line:        line,
+		Column:      column,
+		Interface:   interfaceName,
+		Type:        tc._type,
+		Name:        tc.Name,
+		Message:     d.Message,
+		Replacement: d.Replacement,
+	}
+}
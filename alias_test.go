@@ -0,0 +1,153 @@
+package confection_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/raphaelreyna/confection"
+	"gopkg.in/yaml.v3"
+)
+
+// warnings collects the Warnings reported to a registry.
+type warnings struct {
+	mu   sync.Mutex
+	list []confection.Warning
+}
+
+func (w *warnings) handle(warning confection.Warning) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.list = append(w.list, warning)
+}
+
+func TestAlias_ResolvesAndWarns(t *testing.T) {
+	var w warnings
+	c := confection.NewConfection(confection.WithWarningHandler(w.handle))
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.en", EnglishFactory)
+	confection.RegisterAlias(c, "greetings.english", "greetings.en")
+	confection.Deprecate(c, "greetings.english", confection.Deprecation{Message: "renamed in v2"})
+
+	input := `
+name: old
+typed_config:
+  "@type": greetings.english
+  greeting: Howdy
+`
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	g, err := confection.Make[Greeter](c, tc)
+	if err != nil {
+		t.Fatalf("Make: %s", err)
+	}
+	if got := g.Greet(); got != "Howdy" {
+		t.Errorf("expected %q, got %q", "Howdy", got)
+	}
+
+	if len(w.list) != 1 {
+		t.Fatalf("expected 1 warning, got %d", len(w.list))
+	}
+	got := w.list[0]
+	if got.Line != 4 || got.Type != "greetings.english" || got.Name != "old" || got.Replacement != "greetings.en" {
+		t.Errorf("unexpected warning: %+v", got)
+	}
+	want := `line 4: name "old": interface confection_test.Greeter: @type "greetings.english" is deprecated: renamed in v2; use "greetings.en" instead`
+	if got.String() != want {
+		t.Errorf("unexpected warning text:\n%s\nwant:\n%s", got, want)
+	}
+
+	// the new name is not deprecated
+	tc, err = confection.NewTypedConfig("new", "greetings.en", nil)
+	if err != nil {
+		t.Fatalf("NewTypedConfig: %s", err)
+	}
+	if err := confection.Validate[Greeter](c, tc); err != nil {
+		t.Fatalf("Validate: %s", err)
+	}
+	if len(w.list) != 1 {
+		t.Errorf("expected no warning for the new name, got %+v", w.list[1:])
+	}
+}
+
+func TestDeprecate_Factory(t *testing.T) {
+	var w warnings
+	c := confection.NewConfection(confection.WithWarningHandler(w.handle))
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+	confection.Deprecate(c, "greetings.english", confection.Deprecation{
+		Message:     "use the localized greeter",
+		Replacement: "greetings.localized",
+	})
+
+	tc, err := confection.NewTypedConfig("", "greetings.english", nil)
+	if err != nil {
+		t.Fatalf("NewTypedConfig: %s", err)
+	}
+	if err := confection.Validate[Greeter](c, tc); err != nil {
+		t.Fatalf("Validate: %s", err)
+	}
+	if len(w.list) != 1 || w.list[0].Replacement != "greetings.localized" {
+		t.Errorf("expected a warning naming the replacement, got %+v", w.list)
+	}
+}
+
+func TestTryRegisterAlias_Errors(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.en", EnglishFactory)
+	confection.RegisterAlias(c, "greetings.english", "greetings.en")
+
+	for _, tt := range []struct {
+		alias, target string
+	}{
+		{"greetings.english", "greetings.en"},
+		{"greetings.en", "greetings.english"},
+		{"greetings.english", "greetings.english"},
+	} {
+		if err := confection.TryRegisterAlias(c, tt.alias, tt.target); !errors.Is(err, confection.ErrDuplicateType) {
+			t.Errorf("%s -> %s: expected ErrDuplicateType, got %v", tt.alias, tt.target, err)
+		}
+	}
+	if err := confection.TryRegisterFactory(c, "greetings.english", EnglishFactory); !errors.Is(err, confection.ErrDuplicateType) {
+		t.Errorf("expected ErrDuplicateType registering a factory under an alias, got %v", err)
+	}
+}
+
+func TestAlias_OfAlias(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.en", EnglishFactory)
+	confection.RegisterAlias(c, "english", "greetings.english")
+	confection.RegisterAlias(c, "greetings.english", "greetings.en")
+
+	tc, err := confection.NewTypedConfig("", "english", nil)
+	if err != nil {
+		t.Fatalf("NewTypedConfig: %s", err)
+	}
+	if _, err := confection.Make[Greeter](c, tc); err != nil {
+		t.Fatalf("Make: %s", err)
+	}
+}
+
+func TestAlias_Schema(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.en", EnglishFactory)
+	confection.RegisterAlias(c, "greetings.english", "greetings.en")
+	confection.Deprecate(c, "greetings.english", confection.Deprecation{Message: "renamed"})
+
+	s, err := confection.JSONSchema[Greeter](c)
+	if err != nil {
+		t.Fatalf("JSONSchema: %s", err)
+	}
+	branches := s.Properties["typed_config"].OneOf
+	if len(branches) != 2 {
+		t.Fatalf("expected 2 branches, got %d", len(branches))
+	}
+	if branches[0].Deprecated || !branches[1].Deprecated || branches[1].Description != "renamed" {
+		t.Errorf("expected only the alias to be deprecated, got %+v, %+v", branches[0], branches[1])
+	}
+}
@@ -0,0 +1,311 @@
+package confection
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Build constructs every TypedConfig in config, a struct or a pointer to one,
+// into R, a struct mirroring it. A TypedConfig or *TypedConfig field of
+// config is constructed as the Interface type of the field of R with the same
+// name, and slices, arrays and maps of them into slices, arrays and maps of an
+// Interface. Structs, pointers and the elements of slices, arrays and maps
+// are walked in turn, matched with the corresponding parts of R:
+//
+//	type Config struct {
+//		Listener confection.TypedConfig   `yaml:"listener"`
+//		Filters  []confection.TypedConfig `yaml:"filters"`
+//	}
+//
+//	type Server struct {
+//		Listener Listener
+//		Filters  []Filter
+//	}
+//
+//	server, err := confection.Build[Server](ctx, nil, &cfg)
+//
+// TypedConfigs are constructed one at a time, each after the ones named in
+// its DependsOn and otherwise in the order of config's fields. Empty
+// TypedConfigs are skipped, and TypedConfigs without a field in R are only
+// constructed if they are referred to; as Build does not know which
+// Interface to construct them as, naming one in DependsOn is an error. Build
+// shares named instances through the Scope in ctx, or a new one, with every
+// named TypedConfig in config declared. It returns the first error; pass a
+// context carrying a Lifecycle to release what was built before it.
+// Pass nil for c to use the global registry.
+func Build[R any](ctx context.Context, c *Confection, config any) (R, error) {
+	var result R
+	if err := getConfection(c).build(ctx, config, reflect.ValueOf(&result).Elem()); err != nil {
+		var zero R
+		return zero, err
+	}
+	return result, nil
+}
+
+// build constructs the TypedConfigs in config into result, as Build does.
+func (c *Confection) build(ctx context.Context, config any, result reflect.Value) error {
+	cv := reflect.Indirect(reflect.ValueOf(config))
+	if cv.Kind() != reflect.Struct || result.Kind() != reflect.Struct {
+		return fmt.Errorf("unable to build %T into %s: both must be structs", config, result.Type())
+	}
+
+	var b builder
+	if err := b.walk(cv, result, ""); err != nil {
+		return err
+	}
+	jobs, err := b.order()
+	if err != nil {
+		return err
+	}
+
+	s, ok := ScopeFrom(ctx)
+	if !ok {
+		s = NewScope()
+		ctx = WithScope(ctx, s)
+	}
+	if err := s.Declare(b.configs...); err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		x, err := c.make(ctx, job.t, job.tc)
+		if err != nil {
+			return err
+		}
+		job.set(reflect.ValueOf(x))
+	}
+	// map entries are copies, so they are stored once everything in them is
+	// built
+	for _, store := range b.stores {
+		store()
+	}
+	return nil
+}
+
+// buildJob is a TypedConfig for Build to construct as the Interface t and
+// store with set, in a field of the result or an element of one.
+type buildJob struct {
+	tc  TypedConfig
+	t   reflect.Type
+	set func(reflect.Value)
+}
+
+// builder collects the TypedConfigs of a config struct.
+type builder struct {
+	jobs []*buildJob
+	// configs holds every TypedConfig found, with or without a job
+	configs []TypedConfig
+	// stores put the struct values built for map entries into their maps
+	stores []func()
+}
+
+// walk collects the TypedConfigs in the fields of cv, a struct, matching them
+// with the fields of rv, a struct or the zero Value if there is none.
+func (b *builder) walk(cv, rv reflect.Value, path string) error {
+	for i := range cv.NumField() {
+		field := cv.Type().Field(i)
+		if !field.IsExported() || !holdsTypedConfig(field.Type, map[reflect.Type]bool{}) {
+			continue
+		}
+		var dest reflect.Value
+		if rv.IsValid() {
+			dest = rv.FieldByName(field.Name)
+		}
+		fieldPath := field.Name
+		if path != "" {
+			fieldPath = path + "." + field.Name
+		}
+		if err := b.field(cv.Field(i), dest, fieldPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// field collects the TypedConfigs in v, to be stored in dest if it is valid.
+func (b *builder) field(v, dest reflect.Value, path string) error {
+	t := v.Type()
+	switch {
+	case t == _typedConfigType:
+		tc := v.Interface().(TypedConfig)
+		if tc.TypedConfig == nil {
+			return nil
+		}
+		b.configs = append(b.configs, tc)
+		if !dest.IsValid() {
+			return nil
+		}
+		if !isInterface(dest.Type()) {
+			return fmt.Errorf("unable to build %s: result field is %s, which is not an Interface", path, dest.Type())
+		}
+		b.jobs = append(b.jobs, &buildJob{tc: tc, t: dest.Type(), set: dest.Set})
+	case t.Kind() == reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		if t.Elem() != _typedConfigType && t.Elem().Kind() == reflect.Struct {
+			dest = structDest(dest)
+		}
+		return b.field(v.Elem(), dest, path)
+	case t.Kind() == reflect.Struct:
+		return b.walk(v, structDest(dest), path)
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		if dest.IsValid() {
+			list := dest.Kind() == reflect.Slice || dest.Kind() == reflect.Array
+			switch {
+			case t.Elem() == _typedConfigType && (!list || !isInterface(dest.Type().Elem())):
+				return fmt.Errorf("unable to build %s: result field is %s, which is not a slice of an Interface", path, dest.Type())
+			case dest.Kind() == reflect.Slice:
+				dest.Set(reflect.MakeSlice(dest.Type(), v.Len(), v.Len()))
+			case !list:
+				dest = reflect.Value{}
+			}
+		}
+		for i := range v.Len() {
+			var elem reflect.Value
+			if dest.IsValid() && i < dest.Len() {
+				elem = dest.Index(i)
+			}
+			if err := b.field(v.Index(i), elem, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case t.Kind() == reflect.Map:
+		if dest.IsValid() && (dest.Kind() != reflect.Map || dest.Type().Key() != t.Key()) {
+			if t.Elem() == _typedConfigType {
+				return fmt.Errorf("unable to build %s: result field is %s, which is not a map of an Interface", path, dest.Type())
+			}
+			dest = reflect.Value{}
+		}
+		if dest.IsValid() {
+			dest.Set(reflect.MakeMapWithSize(dest.Type(), v.Len()))
+		}
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+		})
+		for _, key := range keys {
+			if err := b.entry(v.MapIndex(key), dest, key, fmt.Sprintf("%s[%v]", path, key)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// entry collects the TypedConfigs in v, the entry under key of a map, to be
+// stored in the map m if it is valid. Map entries cannot be set in place, so
+// the entry is built in a new value that is stored in m afterwards.
+func (b *builder) entry(v, m, key reflect.Value, path string) error {
+	if !m.IsValid() {
+		return b.field(v, reflect.Value{}, path)
+	}
+	elem := reflect.New(m.Type().Elem()).Elem()
+	if err := b.field(v, elem, path); err != nil {
+		return err
+	}
+	b.stores = append(b.stores, func() { m.SetMapIndex(key, elem) })
+	return nil
+}
+
+// structDest returns the struct dest, or the struct dest points to after
+// setting it to a new one, or the zero Value if dest is neither.
+func structDest(dest reflect.Value) reflect.Value {
+	switch {
+	case !dest.IsValid():
+		return reflect.Value{}
+	case dest.Kind() == reflect.Struct:
+		return dest
+	case dest.Kind() == reflect.Pointer && dest.Type().Elem().Kind() == reflect.Struct:
+		dest.Set(reflect.New(dest.Type().Elem()))
+		return dest.Elem()
+	default:
+		return reflect.Value{}
+	}
+}
+
+// holdsTypedConfig reports whether values of t can hold a TypedConfig. seen
+// holds the types already being checked.
+func holdsTypedConfig(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if t == _typedConfigType {
+		return true
+	}
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return holdsTypedConfig(t.Elem(), seen)
+	case reflect.Struct:
+		for i := range t.NumField() {
+			if f := t.Field(i); f.IsExported() && holdsTypedConfig(f.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// order returns the jobs with each one after those it depends on, and
+// otherwise in the order they were found.
+func (b *builder) order() ([]*buildJob, error) {
+	named := map[string]*buildJob{}
+	for _, job := range b.jobs {
+		if job.tc.Name != "" {
+			named[job.tc.Name] = job
+		}
+	}
+	unbuilt := map[string]bool{}
+	for _, tc := range b.configs {
+		if _, ok := named[tc.Name]; !ok && tc.Name != "" {
+			unbuilt[tc.Name] = true
+		}
+	}
+
+	var (
+		ordered []*buildJob
+		done    = map[*buildJob]bool{}
+		visit   func(job *buildJob, path []string) error
+	)
+	visit = func(job *buildJob, path []string) error {
+		if done[job] {
+			return nil
+		}
+		interfaceName := job.t.String()
+		if name := job.tc.Name; name != "" {
+			if i := slices.Index(path, name); i != -1 {
+				return job.tc.error(interfaceName, &CycleError{Path: append(slices.Clone(path[i:]), name)})
+			}
+			path = append(slices.Clip(path), name)
+		}
+		for _, dep := range job.tc.DependsOn {
+			d, ok := named[dep]
+			if unbuilt[dep] {
+				return job.tc.error(interfaceName, fmt.Errorf("depends_on %q: config has no field in the result to be built as", dep))
+			}
+			if !ok {
+				return job.tc.error(interfaceName, fmt.Errorf("depends_on %q: %w", dep, ErrUnknownReference))
+			}
+			if err := visit(d, path); err != nil {
+				return err
+			}
+		}
+		done[job] = true
+		ordered = append(ordered, job)
+		return nil
+	}
+	for _, job := range b.jobs {
+		if err := visit(job, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// isInterface reports whether t is an interface embedding Interface.
+func isInterface(t reflect.Type) bool {
+	return t.Kind() == reflect.Interface && t.Implements(_interfaceType)
+}
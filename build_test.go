@@ -0,0 +1,330 @@
+package confection_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/raphaelreyna/confection"
+	"gopkg.in/yaml.v3"
+)
+
+type appConfig struct {
+	Primary  confection.TypedConfig   `yaml:"primary"`
+	Others   []confection.TypedConfig `yaml:"others"`
+	Shared   confection.TypedConfig   `yaml:"shared"`
+	Nested   *nestedConfig            `yaml:"nested"`
+	Optional confection.TypedConfig   `yaml:"optional"`
+	Port     int                      `yaml:"port"`
+}
+
+type nestedConfig struct {
+	Wrapper confection.TypedConfig `yaml:"wrapper"`
+}
+
+type app struct {
+	Primary  Greeter
+	Others   []Greeter
+	Nested   *nestedApp
+	Optional Greeter
+}
+
+type nestedApp struct {
+	Wrapper Wrapper
+}
+
+// buildRegistry returns scopeRegistry's registry, recording the name, or else
+// the @type, of each factory call in order.
+func buildRegistry(order *[]string) *confection.Confection {
+	var built atomic.Int32
+	c := scopeRegistry(&built)
+	confection.RegisterInterceptor(c, func(ctx context.Context, call *confection.FactoryCall, next confection.Invoker) (any, error) {
+		if call.Name != "" {
+			*order = append(*order, call.Name)
+		} else {
+			*order = append(*order, call.Type)
+		}
+		return next(ctx, call)
+	})
+	return c
+}
+
+func unmarshalApp(t *testing.T, input string) appConfig {
+	t.Helper()
+	var cfg appConfig
+	if err := yaml.Unmarshal([]byte(input), &cfg); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	return cfg
+}
+
+func TestBuild(t *testing.T) {
+	var order []string
+	c := buildRegistry(&order)
+
+	cfg := unmarshalApp(t, `
+primary:
+  name: primary
+  depends_on: [second]
+  typed_config:
+    "@type": greetings.english
+    greeting: Hi
+others:
+- name: first
+  typed_config: {"@type": greetings.english, greeting: One}
+- name: second
+  depends_on: [first]
+  typed_config: {"@type": greetings.english, greeting: Two}
+shared:
+  name: shared
+  typed_config: {"@type": greetings.english, greeting: Shared}
+nested:
+  wrapper:
+    typed_config:
+      "@type": wrapper
+      prefix: "> "
+      child:
+        typed_config:
+          "@ref": shared
+port: 8080
+`)
+	a, err := confection.Build[app](context.Background(), c, &cfg)
+	if err != nil {
+		t.Fatalf("Build: %s", err)
+	}
+
+	if want := []string{"first", "second", "primary", "wrapper", "shared"}; !slices.Equal(order, want) {
+		t.Errorf("expected construction order %v, got %v", want, order)
+	}
+	if a.Primary.Greet() != "Hi" || len(a.Others) != 2 || a.Others[0].Greet() != "One" || a.Others[1].Greet() != "Two" {
+		t.Errorf("unexpected result %+v", a)
+	}
+	if a.Nested == nil || a.Nested.Wrapper.Inner().Greet() != "Shared" {
+		t.Errorf("expected the nested wrapper around the shared instance, got %+v", a.Nested)
+	}
+	if a.Optional != nil {
+		t.Errorf("expected an absent config to be skipped, got %v", a.Optional)
+	}
+}
+
+func TestBuild_Errors(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		input string
+		is    error
+		want  string
+	}{
+		{
+			name: "cycle",
+			input: `
+primary:
+  name: a
+  depends_on: [b]
+  typed_config: {"@type": greetings.english}
+others:
+- name: b
+  depends_on: [a]
+  typed_config: {"@type": greetings.english}
+`,
+			is:   confection.ErrCycle,
+			want: "dependency cycle: a -> b -> a",
+		},
+		{
+			name: "unknown dependency",
+			input: `
+primary:
+  depends_on: [missing]
+  typed_config: {"@type": greetings.english}
+`,
+			is:   confection.ErrUnknownReference,
+			want: `depends_on "missing"`,
+		},
+		{
+			name: "dependency without a result field",
+			input: `
+primary:
+  depends_on: [shared]
+  typed_config: {"@type": greetings.english}
+shared:
+  name: shared
+  typed_config: {"@type": greetings.english}
+`,
+			want: `depends_on "shared": config has no field in the result`,
+		},
+		{
+			name: "factory",
+			input: `
+others:
+- typed_config: {"@type": greetings.english}
+- typed_config: {"@type": greetings.french}
+`,
+			is:   confection.ErrUnknownType,
+			want: `@type "greetings.french"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var order []string
+			c := buildRegistry(&order)
+			cfg := unmarshalApp(t, tt.input)
+
+			a, err := confection.Build[app](context.Background(), c, cfg)
+			if err == nil || (tt.is != nil && !errors.Is(err, tt.is)) || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected %v containing %q, got %v", tt.is, tt.want, err)
+			}
+			if a.Others != nil {
+				t.Errorf("expected a zero result on error, got %+v", a)
+			}
+		})
+	}
+}
+
+func TestBuild_ResultMismatch(t *testing.T) {
+	var order []string
+	c := buildRegistry(&order)
+	cfg := unmarshalApp(t, `
+primary:
+  typed_config: {"@type": greetings.english}
+`)
+
+	_, err := confection.Build[struct{ Primary string }](context.Background(), c, &cfg)
+	if err == nil || !strings.Contains(err.Error(), "unable to build Primary: result field is string") {
+		t.Errorf("expected a result field error, got %v", err)
+	}
+	if _, err := confection.Build[app](context.Background(), c, 42); err == nil {
+		t.Error("expected an error for a config that is not a struct")
+	}
+	if len(order) != 0 {
+		t.Errorf("expected nothing built, got %v", order)
+	}
+}
+
+func TestTypedConfig_DependsOn(t *testing.T) {
+	input := `name: primary
+depends_on: [first, second]
+typed_config:
+  "@type": greetings.english
+`
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	if !slices.Equal(tc.DependsOn, []string{"first", "second"}) {
+		t.Errorf("expected depends_on [first second], got %v", tc.DependsOn)
+	}
+	if got := marshalYAML(t, tc); got != input {
+		t.Errorf("expected the config written back as is, got:\n%s", got)
+	}
+	tc.DependsOn = nil
+	if got := marshalYAML(t, tc); strings.Contains(got, "depends_on") {
+		t.Errorf("expected depends_on to be removed, got:\n%s", got)
+	}
+
+	// flat configs do not see depends_on
+	c := confection.NewConfection(confection.WithShape(confection.FlatShape), confection.WithStrictDecoding())
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+	useGlobal(t, c)
+
+	input = `depends_on: [first]
+"@type": greetings.english
+greeting: Hi
+`
+	if err := yaml.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	if !slices.Equal(tc.DependsOn, []string{"first"}) {
+		t.Errorf("expected depends_on [first], got %v", tc.DependsOn)
+	}
+	if _, err := confection.Make[Greeter](c, tc); err != nil {
+		t.Errorf("Make: %s", err)
+	}
+	if got := marshalYAML(t, tc); got != input {
+		t.Errorf("expected the flat config written back as is, got:\n%s", got)
+	}
+}
+
+type listenerConfig struct {
+	Filters []confection.TypedConfig `yaml:"filters"`
+}
+
+type containersConfig struct {
+	Listeners []listenerConfig                  `yaml:"listeners"`
+	Routes    map[string]confection.TypedConfig `yaml:"routes"`
+	Groups    map[string]listenerConfig         `yaml:"groups"`
+	Fallback  *confection.TypedConfig           `yaml:"fallback"`
+	Pools     [1]listenerConfig                 `yaml:"pools"`
+}
+
+type listener struct {
+	Filters []Greeter
+}
+
+type containers struct {
+	Listeners []listener
+	Routes    map[string]Greeter
+	Groups    map[string]*listener
+	Fallback  Greeter
+}
+
+func TestBuild_Containers(t *testing.T) {
+	input := `
+listeners:
+- filters:
+  - typed_config: {"@type": greetings.english, greeting: L0}
+- filters:
+  - typed_config: {"@type": greetings.english, greeting: L1}
+  - typed_config: {"@ref": pooled}
+routes:
+  b: {typed_config: {"@type": greetings.english, greeting: B}}
+  a: {typed_config: {"@type": greetings.english, greeting: A}}
+groups:
+  main:
+    filters:
+    - typed_config: {"@type": greetings.english, greeting: G}
+fallback:
+  typed_config: {"@type": greetings.english, greeting: F}
+pools:
+- filters:
+  - name: pooled
+    typed_config: {"@type": greetings.english, greeting: P}
+`
+	var order []string
+	c := buildRegistry(&order)
+	var cfg containersConfig
+	if err := yaml.Unmarshal([]byte(input), &cfg); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	r, err := confection.Build[containers](context.Background(), c, &cfg)
+	if err != nil {
+		t.Fatalf("Build: %s", err)
+	}
+
+	var got []string
+	for _, l := range r.Listeners {
+		for _, f := range l.Filters {
+			got = append(got, f.Greet())
+		}
+	}
+	if want := []string{"L0", "L1", "P"}; !slices.Equal(got, want) {
+		t.Errorf("expected listener filters %v, got %v", want, got)
+	}
+	if len(r.Routes) != 2 || r.Routes["a"].Greet() != "A" || r.Routes["b"].Greet() != "B" {
+		t.Errorf("expected the routes by key, got %v", r.Routes)
+	}
+	if g := r.Groups["main"]; g == nil || len(g.Filters) != 1 || g.Filters[0].Greet() != "G" {
+		t.Errorf("expected the group's filters, got %+v", g)
+	}
+	if r.Fallback == nil || r.Fallback.Greet() != "F" {
+		t.Errorf("expected the fallback, got %v", r.Fallback)
+	}
+
+	// every nested config is checked
+	cfg.Listeners[1].Filters[0] = unmarshalApp(t, `primary: {typed_config: {"@type": greetings.french}}`).Primary
+	_, err = confection.Build[containers](context.Background(), c, &cfg)
+	if !errors.Is(err, confection.ErrUnknownType) || !strings.Contains(err.Error(), `@type "greetings.french"`) {
+		t.Errorf("expected ErrUnknownType for the nested filter, got %v", err)
+	}
+}
@@ -2,43 +2,123 @@ package confection
 
 import (
 	"context"
+	"reflect"
 	"sync"
 
 	"gopkg.in/yaml.v3"
 )
 
 type _interface struct {
-	registeredTypes map[string]func(context.Context, *yaml.Node) (any, error)
+	_type           reflect.Type
+	registeredTypes map[string]*registration
+	resolvers       []Resolver
+	decorators      []func(context.Context, any) (any, error)
+}
+
+// registration is a factory bound to a @type name for a single Interface.
+// Decoding is kept separate from construction so configs can be validated
+// without calling the factory.
+type registration struct {
+	configType reflect.Type
+	implType   reflect.Type
+	decode     func(*yaml.Node) (any, error)
+	build      func(context.Context, any) (any, error)
 }
 
 // Confection is a typed configuration registry that maps interface types
 // to factory functions, enabling config-driven polymorphism.
 type Confection struct {
-	mu         sync.RWMutex
-	interfaces map[string]*_interface
+	mu          sync.RWMutex
+	interfaces  map[string]*_interface
+	conversions map[string]*conversion
+	// aliases maps alias @type names to the names they stand for.
+	aliases      map[string]string
+	deprecations map[string]Deprecation
+	resolvers    []Resolver
+	interceptors []Interceptor
+	parent       *Confection
+	sealed       bool
+	warn         WarningHandler
+	strict       bool
+	format       format
+}
+
+// Option configures a Confection created by NewConfection.
+type Option func(*Confection)
+
+// WithStrictDecoding makes Make and Validate reject typed_config keys that do
+// not match a field of the factory's Configuration type, instead of ignoring them.
+func WithStrictDecoding() Option {
+	return func(c *Confection) {
+		c.strict = true
+	}
 }
 
 func (c *Confection) String() string {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	unlock := c.rlock()
+	defer unlock()
 	s := ""
-	for k, v := range c.interfaces {
-		if len(v.registeredTypes) == 0 {
+	for k := range c.interfaceTypes() {
+		registeredTypes := c.factories(k)
+		if len(registeredTypes) == 0 {
 			s += "Interface " + k + " has no registered types\n"
 			continue
 		}
 		s += "Interface: " + k + "\n"
-		for k := range v.registeredTypes {
+		for k := range registeredTypes {
 			s += "  Config: " + k + "\n"
 		}
 	}
 	return s
 }
 
-// NewConfection creates a new, empty Confection registry.
-func NewConfection() *Confection {
+// resolution is what a @type name resolves to under an Interface.
+type resolution struct {
+	// reg is the factory to call, or nil if the @type is not registered.
+	reg *registration
+	// chain is the conversions to apply to the config before calling reg.
+	chain []*conversion
+	// deprecation is set if the @type, or the type it is an alias of, is deprecated.
+	deprecation *Deprecation
+	// typeName is the @type after following aliases.
+	typeName string
+}
+
+// lookup resolves typeName under the named Interface, following aliases and
+// conversions. ok is false if the Interface is not registered.
+func (c *Confection) lookup(interfaceName, typeName string) (res resolution, ok bool) {
+	unlock := c.rlock()
+	defer unlock()
+
+	if _, ok := c.interfaceType(interfaceName); !ok {
+		return resolution{}, false
+	}
+	target := c.aliasTarget(typeName)
+	res.typeName = target
+	res.chain, res.reg = c.conversionChain(interfaceName, target)
+	res.deprecation = c.deprecation(typeName, target)
+	return res, true
+}
+
+// NewConfection creates a new, empty Confection registry configured with opts.
+func NewConfection(opts ...Option) *Confection {
 	c := Confection{
-		interfaces: make(map[string]*_interface, 0),
+		interfaces:   make(map[string]*_interface, 0),
+		conversions:  make(map[string]*conversion),
+		aliases:      make(map[string]string),
+		deprecations: make(map[string]Deprecation),
+		warn:         logWarning,
+		format:       defaultFormat,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	// start from the parent's options, then apply opts again to override them
+	if c.parent != nil {
+		c.strict, c.format, c.warn = c.parent.strict, c.parent.format, c.parent.warn
+		for _, opt := range opts {
+			opt(&c)
+		}
 	}
 
 	return &c
@@ -59,6 +139,15 @@ func getGlobal() *Confection {
 	return Global
 }
 
+// globalFormat returns the format of the global registry, or the default
+// format if Global has been set to nil.
+func globalFormat() format {
+	if g := getGlobal(); g != nil {
+		return g.format
+	}
+	return defaultFormat
+}
+
 func getConfection(c *Confection) *Confection {
 	if c == nil {
 		return getGlobal()
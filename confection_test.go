@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/raphaelreyna/confection"
 	"gopkg.in/yaml.v3"
@@ -214,6 +217,94 @@ typed_config:
 	}
 }
 
+func TestConcurrent_NestedMakeAndRegister(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterInterface[Wrapper](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+
+	var registered atomic.Int64
+	register := func() {
+		name := fmt.Sprintf("greetings.english.%d", registered.Add(1))
+		confection.RegisterFactory(c, name, EnglishFactory)
+	}
+
+	// the outer factory waits for a registration to complete before making its
+	// child; this deadlocks if the registry lock is held across factory calls
+	wrapperFactory := func(ctx context.Context, cfg *WrapperConfig) (*WrapperImpl, error) {
+		done := make(chan struct{})
+		go func() {
+			register()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			return nil, fmt.Errorf("registration blocked while factory was running")
+		}
+
+		inner, err := confection.MakeCtx[Greeter](ctx, c, cfg.Child)
+		if err != nil {
+			return nil, err
+		}
+		return &WrapperImpl{inner: inner, prefix: cfg.Prefix}, nil
+	}
+	confection.RegisterFactory(c, "wrapper", wrapperFactory)
+
+	input := `
+name: outer
+typed_config:
+  "@type": wrapper
+  child:
+    name: inner
+    typed_config:
+      "@type": greetings.english
+      greeting: Hola
+`
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+
+	const n = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*n)
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			w, err := confection.Make[Wrapper](c, tc)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if w.Inner().Greet() != "Hola" {
+				errs <- fmt.Errorf("expected 'Hola', got %q", w.Inner().Greet())
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			register()
+		}()
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(10 * time.Second):
+		t.Fatal("deadlock: nested Make and concurrent registration did not finish")
+	}
+
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
 // --- slice and nested TypedConfig tests ---
 
 func TestSliceOfTypedConfig(t *testing.T) {
@@ -0,0 +1,171 @@
+package confection
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Converter migrates a configuration from one version of a type to the next.
+type Converter[From any, To any] func(From) (To, error)
+
+// conversion is a Converter bound to the @type names it converts between.
+type conversion struct {
+	from, to         string
+	fromType, toType reflect.Type
+	decode           func(*yaml.Node) (any, error)
+	convert          func(any) (any, error)
+}
+
+// RegisterConversion registers convert as the migration from configs of
+// @type fromType to configs of @type toType, so that one type can evolve
+// through several versions without rewriting existing documents:
+//
+//	confection.RegisterFactory(nil, "greetings.spanish/v2", NewSpanishV2)
+//	confection.RegisterConversion(nil, "greetings.spanish/v1", "greetings.spanish/v2", SpanishV1ToV2)
+//
+// When Make meets a config whose @type has conversions, it follows them as far
+// as the newest type with a factory for the requested Interface, decoding the
+// config into From, converting it step by step and calling that factory. A
+// type with conversions out of it is converted even if it has a factory of
+// its own. Conversions are shared by every Interface in the registry.
+//
+// From is decoded with the same Defaulter and Validator hooks as factory
+// configs, and each converted To value has them run again. To must be the
+// Configuration type of the next conversion or factory in the chain.
+// Pass nil for c to use the global registry.
+// Panics if fromType already has a conversion, if the conversion would loop,
+// or if c is sealed.
+func RegisterConversion[From any, To any](c *Confection, fromType, toType string, convert Converter[From, To]) {
+	if err := TryRegisterConversion(c, fromType, toType, convert); err != nil {
+		panic(err)
+	}
+}
+
+// TryRegisterConversion is like RegisterConversion but returns an error
+// wrapping ErrDuplicateConversion or ErrSealed instead of panicking.
+func TryRegisterConversion[From any, To any](c *Confection, fromType, toType string, convert Converter[From, To]) error {
+	conf := getConfection(c)
+
+	conv := &conversion{
+		from:     fromType,
+		to:       toType,
+		fromType: reflect.TypeFor[From](),
+		toType:   reflect.TypeFor[To](),
+		decode: func(node *yaml.Node) (any, error) {
+			var config From
+			if err := node.Decode(&config); err != nil {
+				return nil, err
+			}
+			if err := prepareConfig(&config); err != nil {
+				return nil, err
+			}
+			return config, nil
+		},
+		convert: func(config any) (any, error) {
+			converted, err := convert(config.(From))
+			if err != nil {
+				return nil, err
+			}
+			if err := prepareConfig(&converted); err != nil {
+				return nil, err
+			}
+			return converted, nil
+		},
+	}
+
+	unlock := conf.lock()
+	defer unlock()
+
+	if conf.sealed {
+		return fmt.Errorf("unable to register conversion from %q to %q: %w", fromType, toType, ErrSealed)
+	}
+	if _, exists := conf.conversions[fromType]; exists {
+		return fmt.Errorf("unable to register conversion from %q to %q: %w", fromType, toType, ErrDuplicateConversion)
+	}
+	// existing conversions never loop, so following them from toType ends
+	loops := toType == fromType
+	for next := conf.conversion(toType); next != nil && !loops; next = conf.conversion(next.to) {
+		loops = next.to == fromType
+	}
+	if loops {
+		return fmt.Errorf("unable to register conversion from %q to %q: conversions would loop: %w", fromType, toType, ErrDuplicateConversion)
+	}
+	conf.conversions[fromType] = conv
+
+	return nil
+}
+
+// conversionChain returns the conversions leading from typeName to the newest
+// type in its chain with a factory for the named Interface, and that factory.
+// Conversions are followed past types without a factory. The caller must hold
+// c.rlock.
+func (c *Confection) conversionChain(interfaceName, typeName string) ([]*conversion, *registration) {
+	var (
+		chain, best []*conversion
+		reg         = c.factory(interfaceName, typeName)
+		seen        = map[string]bool{typeName: true}
+	)
+	// a parent may add a conversion that loops with a child's, so stop at a
+	// type already seen
+	for conv := c.conversion(typeName); conv != nil && !seen[conv.to]; conv = c.conversion(conv.to) {
+		seen[conv.to] = true
+		chain = append(chain, conv)
+		if r := c.factory(interfaceName, conv.to); r != nil {
+			best, reg = chain, r
+		}
+	}
+	return best, reg
+}
+
+// convertConfig decodes node through chain and returns the config reg takes.
+func convertConfig(node *yaml.Node, chain []*conversion, reg *registration) (any, error) {
+	config, err := chain[0].decode(node)
+	if err != nil {
+		return nil, err
+	}
+	for i, conv := range chain {
+		want := reg.configType
+		if i+1 < len(chain) {
+			want = chain[i+1].fromType
+		}
+		if conv.toType != want {
+			return nil, fmt.Errorf("converting %q to %q: %w: got %s, want %s", conv.from, conv.to, ErrConversionMismatch, conv.toType, want)
+		}
+		if config, err = conv.convert(config); err != nil {
+			return nil, fmt.Errorf("converting %q to %q: %w", conv.from, conv.to, err)
+		}
+	}
+	return config, nil
+}
+
+// convertibleTypes describes the @type names that have no factory of their own
+// for the named Interface but convert to one, with the Configuration type
+// their configs are decoded into, sorted by name.
+func (c *Confection) convertibleTypes(interfaceName string) []FactoryInfo {
+	unlock := c.rlock()
+	defer unlock()
+
+	var infos []FactoryInfo
+	for _, typeName := range c.names(func(r *Confection) []string { return slices.Collect(maps.Keys(r.conversions)) }) {
+		if c.factory(interfaceName, typeName) != nil {
+			continue
+		}
+		if chain, reg := c.conversionChain(interfaceName, typeName); len(chain) > 0 {
+			infos = append(infos, FactoryInfo{
+				Interface:      interfaceName,
+				Type:           typeName,
+				Config:         chain[0].fromType,
+				Implementation: reg.implType,
+			})
+		}
+	}
+	slices.SortFunc(infos, func(a, b FactoryInfo) int {
+		return strings.Compare(a.Type, b.Type)
+	})
+	return infos
+}
@@ -0,0 +1,166 @@
+package confection_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/raphaelreyna/confection"
+	"gopkg.in/yaml.v3"
+)
+
+// SpanishV1Config is the original Spanish config, before formality became a
+// named register.
+type SpanishV1Config struct {
+	Formal bool `yaml:"formal"`
+}
+
+type SpanishV2Config struct {
+	Register string `yaml:"register"`
+}
+
+type SpanishV3Config struct {
+	Register string `yaml:"register"`
+	Name     string `yaml:"name"`
+}
+
+func (c *SpanishV3Config) SetDefaults() {
+	if c.Name == "" {
+		c.Name = "amigo"
+	}
+}
+
+func SpanishV3Factory(_ context.Context, cfg SpanishV3Config) (*Spanish, error) {
+	if cfg.Register == "formal" {
+		return &Spanish{phrase: "Buenos días, " + cfg.Name}, nil
+	}
+	return &Spanish{phrase: "Hola, " + cfg.Name}, nil
+}
+
+func SpanishV1ToV2(v1 SpanishV1Config) (SpanishV2Config, error) {
+	if v1.Formal {
+		return SpanishV2Config{Register: "formal"}, nil
+	}
+	return SpanishV2Config{Register: "informal"}, nil
+}
+
+func SpanishV2ToV3(v2 SpanishV2Config) (SpanishV3Config, error) {
+	return SpanishV3Config{Register: v2.Register}, nil
+}
+
+func versionedRegistry(opts ...confection.Option) *confection.Confection {
+	c := confection.NewConfection(opts...)
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.spanish/v3", SpanishV3Factory)
+	confection.RegisterConversion(c, "greetings.spanish/v1", "greetings.spanish/v2", SpanishV1ToV2)
+	confection.RegisterConversion(c, "greetings.spanish/v2", "greetings.spanish/v3", SpanishV2ToV3)
+	return c
+}
+
+func TestConversion_UpgradesOldVersions(t *testing.T) {
+	c := versionedRegistry()
+
+	for _, tt := range []struct {
+		input string
+		want  string
+	}{
+		{"typed_config:\n  \"@type\": greetings.spanish/v1\n  formal: true\n", "Buenos días, amigo"},
+		{"typed_config:\n  \"@type\": greetings.spanish/v2\n  register: informal\n", "Hola, amigo"},
+		{"typed_config:\n  \"@type\": greetings.spanish/v3\n  register: formal\n  name: señor\n", "Buenos días, señor"},
+	} {
+		var tc confection.TypedConfig
+		if err := yaml.Unmarshal([]byte(tt.input), &tc); err != nil {
+			t.Fatalf("unmarshal: %s", err)
+		}
+		if err := confection.Validate[Greeter](c, tc); err != nil {
+			t.Errorf("%s: Validate: %s", tc.Type(), err)
+		}
+		g, err := confection.Make[Greeter](c, tc)
+		if err != nil {
+			t.Fatalf("%s: Make: %s", tc.Type(), err)
+		}
+		if got := g.Greet(); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tc.Type(), tt.want, got)
+		}
+	}
+}
+
+func TestConversion_StrictChecksOldFields(t *testing.T) {
+	c := versionedRegistry(confection.WithStrictDecoding())
+
+	input := "typed_config:\n  \"@type\": greetings.spanish/v1\n  register: formal\n"
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	if _, err := confection.Make[Greeter](c, tc); !errors.Is(err, confection.ErrUnknownField) {
+		t.Fatalf("expected ErrUnknownField for a v2 field in a v1 config, got %v", err)
+	}
+}
+
+func TestConversion_Errors(t *testing.T) {
+	errNoFormality := errors.New("formality is required")
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.spanish/v3", SpanishV3Factory)
+	confection.RegisterConversion(c, "greetings.spanish/v1", "greetings.spanish/v3", func(SpanishV1Config) (SpanishV3Config, error) {
+		return SpanishV3Config{}, errNoFormality
+	})
+	// the factory takes SpanishV3Config, not a pointer to it
+	confection.RegisterConversion(c, "greetings.spanish/v2", "greetings.spanish/v3", func(SpanishV2Config) (*SpanishV3Config, error) {
+		return &SpanishV3Config{}, nil
+	})
+
+	for _, tt := range []struct {
+		typeName string
+		want     error
+	}{
+		{"greetings.spanish/v1", errNoFormality},
+		{"greetings.spanish/v2", confection.ErrConversionMismatch},
+	} {
+		tc, err := confection.NewTypedConfig("", tt.typeName, nil)
+		if err != nil {
+			t.Fatalf("NewTypedConfig: %s", err)
+		}
+		_, err = confection.Make[Greeter](c, tc)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.typeName, tt.want, err)
+		}
+		var e *confection.Error
+		if !errors.As(err, &e) || e.Type != tt.typeName {
+			t.Errorf("%s: expected *Error for the original @type, got %v", tt.typeName, err)
+		}
+	}
+}
+
+func TestTryRegisterConversion_DuplicateAndLoop(t *testing.T) {
+	c := versionedRegistry()
+
+	err := confection.TryRegisterConversion(c, "greetings.spanish/v1", "greetings.spanish/v3", SpanishV2ToV3)
+	if !errors.Is(err, confection.ErrDuplicateConversion) {
+		t.Errorf("expected ErrDuplicateConversion for a second conversion, got %v", err)
+	}
+	err = confection.TryRegisterConversion(c, "greetings.spanish/v3", "greetings.spanish/v1", func(SpanishV3Config) (SpanishV1Config, error) {
+		return SpanishV1Config{}, nil
+	})
+	if !errors.Is(err, confection.ErrDuplicateConversion) {
+		t.Errorf("expected ErrDuplicateConversion for a loop, got %v", err)
+	}
+}
+
+func TestConversion_Schema(t *testing.T) {
+	c := versionedRegistry()
+
+	s, err := confection.JSONSchema[Greeter](c)
+	if err != nil {
+		t.Fatalf("JSONSchema: %s", err)
+	}
+	branches := s.Properties["typed_config"].OneOf
+	if len(branches) != 3 {
+		t.Fatalf("expected a branch per version, got %d", len(branches))
+	}
+	v1 := branches[1]
+	if v1.Title != "greetings.spanish/v1" || v1.Properties["formal"] == nil {
+		t.Errorf("expected v1 branch with its own fields, got %+v", v1)
+	}
+}
@@ -0,0 +1,80 @@
+package confection
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// Decorator wraps an instance of interface I constructed by MakeCtx, for
+// example with tracing, caching or circuit breaking, and returns the instance
+// to use in its place.
+type Decorator[I Interface] func(context.Context, I) (I, error)
+
+// RegisterDecorator adds d to the decorators MakeCtx applies to every instance
+// of I it constructs with c, whatever its @type. Decorators run in the order
+// they were registered, those of a parent registry first, after the factory
+// and any Interceptors; each receives the previous one's result, which must be
+// a non-nil I. An error from a decorator is returned from MakeCtx as *Error.
+// Pass nil for c to use the global registry.
+// Panics if I is not registered or c is sealed.
+func RegisterDecorator[I Interface](c *Confection, d Decorator[I]) {
+	if err := TryRegisterDecorator(c, d); err != nil {
+		panic(err)
+	}
+}
+
+// TryRegisterDecorator is like RegisterDecorator but returns an error wrapping
+// ErrInterfaceNotRegistered or ErrSealed instead of panicking.
+func TryRegisterDecorator[I Interface](c *Confection, d Decorator[I]) error {
+	conf := getConfection(c)
+	name := reflect.TypeFor[I]().String()
+
+	unlock := conf.lock()
+	defer unlock()
+
+	if conf.sealed {
+		return fmt.Errorf("unable to register decorator for Interface %q: %w", name, ErrSealed)
+	}
+	if _, ok := conf.interfaceType(name); !ok {
+		return fmt.Errorf("unable to register decorator for Interface %q: %w", name, ErrInterfaceNotRegistered)
+	}
+	iface := conf.localInterface(name)
+	iface.decorators = append(iface.decorators, func(ctx context.Context, x any) (any, error) {
+		impl, ok := x.(I)
+		if !ok {
+			return nil, fmt.Errorf("decorator returned %T, which does not implement %s", x, name)
+		}
+		return d(ctx, impl)
+	})
+
+	return nil
+}
+
+// decorate applies c's decorators for the named Interface to x.
+func (c *Confection) decorate(ctx context.Context, interfaceName string, x any) (any, error) {
+	for _, d := range c.decoratorsFor(interfaceName) {
+		decorated, err := d(ctx, x)
+		if err != nil {
+			return x, fmt.Errorf("decorator: %w", err)
+		}
+		x = decorated
+	}
+	return x, nil
+}
+
+// decoratorsFor returns the decorators for the named Interface, in the order
+// they apply.
+func (c *Confection) decoratorsFor(interfaceName string) []func(context.Context, any) (any, error) {
+	unlock := c.rlock()
+	defer unlock()
+
+	var decorators []func(context.Context, any) (any, error)
+	for _, r := range slices.Backward(c.lineage()) {
+		if iface, ok := r.interfaces[interfaceName]; ok {
+			decorators = append(decorators, iface.decorators...)
+		}
+	}
+	return decorators
+}
@@ -0,0 +1,105 @@
+package confection_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/raphaelreyna/confection"
+)
+
+// shouting decorates a Greeter by adding a suffix to its greeting.
+type shouting struct {
+	Greeter
+	suffix string
+}
+
+func (s *shouting) Greet() string { return s.Greeter.Greet() + s.suffix }
+
+func suffixDecorator(suffix string) confection.Decorator[Greeter] {
+	return func(_ context.Context, g Greeter) (Greeter, error) {
+		return &shouting{Greeter: g, suffix: suffix}, nil
+	}
+}
+
+func TestDecorator_AppliesInOrder(t *testing.T) {
+	parent := confection.NewConfection()
+	confection.RegisterInterface[Greeter](parent)
+	confection.RegisterFactory(parent, "greetings.english", EnglishFactory)
+	confection.RegisterFactory(parent, "greetings.spanish", SpanishFactory)
+	confection.RegisterDecorator(parent, suffixDecorator("!"))
+
+	child := confection.NewConfection(confection.WithParent(parent))
+	confection.RegisterDecorator(child, suffixDecorator("?"))
+	confection.RegisterDecorator(child, suffixDecorator("."))
+
+	if got := greet(t, child, "greetings.english"); got != "Hello!?." {
+		t.Errorf("expected decorators in registration order, parent first, got %q", got)
+	}
+	if got := greet(t, child, "greetings.spanish"); got != "Hola, ¿cómo estás?!?." {
+		t.Errorf("expected decorators for every @type, got %q", got)
+	}
+	if got := greet(t, parent, "greetings.english"); got != "Hello!" {
+		t.Errorf("expected the parent to have only its own decorator, got %q", got)
+	}
+}
+
+func TestDecorator_Error(t *testing.T) {
+	errOpen := errors.New("circuit open")
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+	confection.RegisterDecorator(c, func(context.Context, Greeter) (Greeter, error) {
+		return nil, errOpen
+	})
+
+	tc, err := confection.NewTypedConfig("", "greetings.english", nil)
+	if err != nil {
+		t.Fatalf("NewTypedConfig: %s", err)
+	}
+	_, err = confection.Make[Greeter](c, tc)
+	if !errors.Is(err, errOpen) {
+		t.Fatalf("expected decorator error, got %v", err)
+	}
+	var e *confection.Error
+	if !errors.As(err, &e) || e.Type != "greetings.english" {
+		t.Errorf("expected *Error for the @type, got %v", err)
+	}
+}
+
+func TestDecorator_NilResult(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+	confection.RegisterDecorator(c, func(context.Context, Greeter) (Greeter, error) {
+		return nil, nil
+	})
+
+	tc, err := confection.NewTypedConfig("", "greetings.english", nil)
+	if err != nil {
+		t.Fatalf("NewTypedConfig: %s", err)
+	}
+	want := "decorator returned <nil>, which does not implement confection_test.Greeter"
+	if _, err := confection.Make[Greeter](c, tc); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("expected %q, got %v", want, err)
+	}
+
+	// a decorator after it is not handed the nil instance
+	confection.RegisterDecorator(c, suffixDecorator("!"))
+	if _, err := confection.Make[Greeter](c, tc); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("expected %q, got %v", want, err)
+	}
+}
+
+func TestTryRegisterDecorator_Errors(t *testing.T) {
+	c := confection.NewConfection()
+	if err := confection.TryRegisterDecorator(c, suffixDecorator("!")); !errors.Is(err, confection.ErrInterfaceNotRegistered) {
+		t.Errorf("expected ErrInterfaceNotRegistered, got %v", err)
+	}
+	confection.RegisterInterface[Greeter](c)
+	c.Seal()
+	if err := confection.TryRegisterDecorator(c, suffixDecorator("!")); !errors.Is(err, confection.ErrSealed) {
+		t.Errorf("expected ErrSealed, got %v", err)
+	}
+}
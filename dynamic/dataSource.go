@@ -2,6 +2,7 @@ package dynamic
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -33,13 +34,9 @@ func (ds *DataSource) UnmarshalYAML(value *yaml.Node) error {
 		key := value.Content[idx].Value
 		val := value.Content[idx+1].Value
 
-		factory, ok := reg.lookup(key)
-		if !ok {
-			return fmt.Errorf("line %d: unknown data source type %s", value.Content[idx].Line, key)
-		}
-		rc, err := factory(val)
+		rc, err := reg.open(key, val)
 		if err != nil {
-			return fmt.Errorf("line %d: data source %s: %w", value.Content[idx].Line, key, err)
+			return fmt.Errorf("line %d: %w", value.Content[idx].Line, err)
 		}
 		readCloser = rc
 		break
@@ -48,11 +45,67 @@ func (ds *DataSource) UnmarshalYAML(value *yaml.Node) error {
 		return errors.New("data source type not found")
 	}
 
+	ds.set(readCloser)
+
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The JSON form mirrors the YAML
+// form: an object whose first key selects the source type, such as
+// {"file": "/etc/secrets/key.pem"}. encoding/json passes data without the
+// rest of the document, so errors name the source type rather than a
+// position; a DataSource decoded with confection.DecodeJSON goes through
+// UnmarshalYAML and reports the line within the document.
+func (ds *DataSource) UnmarshalJSON(data []byte) error {
+	if ds == nil {
+		return errors.New("data source is nil")
+	}
+
+	reg := getRegistry(ds.Registry)
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('{') {
+		return errors.New("data source must be an object")
+	}
+	if !dec.More() {
+		return errors.New("data source type not found")
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	key := tok.(string)
+	if tok, err = dec.Token(); err != nil {
+		return err
+	}
+	var val string
+	switch t := tok.(type) {
+	case string:
+		val = t
+	case json.Number, bool:
+		val = fmt.Sprint(t)
+	default:
+		return fmt.Errorf("data source %s: value must be a scalar", key)
+	}
+
+	readCloser, err := reg.open(key, val)
+	if err != nil {
+		return err
+	}
+
+	ds.set(readCloser)
+
+	return nil
+}
+
+func (ds *DataSource) set(readCloser io.ReadCloser) {
 	ds.read = readCloser.Read
 	ds.close = readCloser.Close
 	ds.ReadCloser = readCloser
-
-	return nil
 }
 
 func (ds *DataSource) Read(p []byte) (n int, err error) {
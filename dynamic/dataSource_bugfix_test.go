@@ -1,6 +1,7 @@
 package dynamic_test
 
 import (
+	"encoding/json"
 	"io"
 	"os"
 	"path/filepath"
@@ -128,3 +129,30 @@ func TestDataSource_CloseBeforeInit(t *testing.T) {
 		t.Fatal("expected error closing uninitialized DataSource, got nil")
 	}
 }
+
+func TestDataSource_UnmarshalJSON(t *testing.T) {
+	var cfg struct {
+		Source dynamic.DataSource `json:"source"`
+	}
+	if err := json.Unmarshal([]byte(`{"source": {"string": "hello json"}}`), &cfg); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	data, err := io.ReadAll(&cfg.Source)
+	if err != nil {
+		t.Fatalf("read: %s", err)
+	}
+	if string(data) != "hello json" {
+		t.Errorf("expected 'hello json', got %q", string(data))
+	}
+}
+
+func TestDataSource_UnmarshalJSON_UnknownType(t *testing.T) {
+	var ds dynamic.DataSource
+	err := json.Unmarshal([]byte(`{ "nope": "value"}`), &ds)
+	if err == nil {
+		t.Fatal("expected error for unknown source type, got nil")
+	}
+	if !strings.Contains(err.Error(), "unknown data source type nope") || strings.Contains(err.Error(), "offset") {
+		t.Errorf("expected the source type without an offset in error, got: %s", err)
+	}
+}
@@ -73,3 +73,16 @@ func (reg *Registry) lookup(name string) (SourceFactory, bool) {
 	f, ok := reg.sources[name]
 	return f, ok
 }
+
+// open resolves the named source type and creates its io.ReadCloser.
+func (reg *Registry) open(name, value string) (io.ReadCloser, error) {
+	factory, ok := reg.lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown data source type %s", name)
+	}
+	rc, err := factory(value)
+	if err != nil {
+		return nil, fmt.Errorf("data source %s: %w", name, err)
+	}
+	return rc, nil
+}
@@ -0,0 +1,179 @@
+package confection
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrDuplicateInterface is returned when an Interface is registered twice.
+	ErrDuplicateInterface = errors.New("Interface already registered")
+	// ErrDuplicateType is returned when a @type name is registered twice for the same Interface.
+	ErrDuplicateType = errors.New("configuration type double registration")
+	// ErrInterfaceNotRegistered is returned when an Interface has not been registered.
+	ErrInterfaceNotRegistered = errors.New("Interface not registered")
+	// ErrInvalidImplementation is returned when a factory's Implementation type
+	// is not a pointer to a struct or carries an invalid confection tag.
+	ErrInvalidImplementation = errors.New("invalid implementation")
+	// ErrUnknownType is returned when a @type is not registered for the requested Interface.
+	ErrUnknownType = errors.New("type not registered")
+	// ErrMissingType is returned when a typed_config block has no @type.
+	ErrMissingType = errors.New("@type not found in typed_config")
+	// ErrUnknownField is returned in strict decoding mode when a typed_config
+	// key does not match a field of the factory's Configuration type.
+	ErrUnknownField = errors.New("unknown field")
+	// ErrMissingTypedConfig is returned when a TypedConfig has no typed_config block.
+	ErrMissingTypedConfig = errors.New("typed_config is required")
+	// ErrDuplicateConversion is returned when a conversion from a @type name
+	// is registered twice, or would make the conversions from it loop.
+	ErrDuplicateConversion = errors.New("conversion already registered")
+	// ErrSealed is returned when registering with a sealed Confection.
+	ErrSealed = errors.New("registry is sealed")
+	// ErrConversionMismatch is returned when a conversion produces a
+	// Configuration type other than the one the next step of the chain takes.
+	ErrConversionMismatch = errors.New("conversion result does not match configuration type")
+	// ErrUnknownReference is returned when a reference names no TypedConfig
+	// declared or built in the Scope.
+	ErrUnknownReference = errors.New("reference to unknown name")
+	// ErrDuplicateName is returned when two different TypedConfigs in a Scope
+	// have the same name.
+	ErrDuplicateName = errors.New("name already used in scope")
+	// ErrCycle is matched by *CycleError, returned when references or
+	// depends_on lists loop.
+	ErrCycle = errors.New("dependency cycle")
+)
+
+// Error is returned when a TypedConfig cannot be parsed or constructed.
+// It locates the failure in the source document and wraps its cause,
+// which can be inspected with errors.Is and errors.As.
+type Error struct {
+	// Line and Column locate the offending YAML node. They are zero for a
+	// TypedConfig decoded on its own by encoding/json, whose positions are
+	// only known within the TypedConfig; see DecodeJSON.
+	Line   int
+	Column int
+	// Path is the YAML path to the offending node, such as
+	// listeners[0].filters[2].typed_config. It is filled in by ResolvePath,
+	// and is relative to the TypedConfig if Line is zero.
+	Path string
+	// Interface is the name of the Interface being constructed, if any.
+	Interface string
+	// Type is the @type of the TypedConfig, if known.
+	Type string
+	// Name is the name of the TypedConfig, if known.
+	Name string
+	// Err is the underlying cause.
+	Err error
+}
+
+func (e *Error) Error() string {
+	var parts []string
+	switch {
+	case e.Line > 0 && e.Path != "":
+		parts = append(parts, fmt.Sprintf("line %d (%s)", e.Line, e.Path))
+	case e.Line > 0:
+		parts = append(parts, fmt.Sprintf("line %d", e.Line))
+	case e.Path != "":
+		parts = append(parts, e.Path)
+	}
+	if e.Name != "" {
+		parts = append(parts, fmt.Sprintf("name %q", e.Name))
+	}
+	if e.Interface != "" {
+		parts = append(parts, "interface "+e.Interface)
+	}
+	if e.Type != "" {
+		parts = append(parts, fmt.Sprintf("@type %q", e.Type))
+	}
+	if e.Err != nil {
+		parts = append(parts, e.Err.Error())
+	}
+	return strings.Join(parts, ": ")
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ResolvePath fills in the Path of every *Error in err's chain using root,
+// the document the failing TypedConfig was decoded from. It returns err.
+func ResolvePath(root *yaml.Node, err error) error {
+	walkErrors(err, func(e *Error) {
+		if e.Path != "" {
+			return
+		}
+		if path, ok := nodePath(root, e.Line, e.Column); ok {
+			e.Path = path
+		}
+	})
+	return err
+}
+
+func walkErrors(err error, fn func(*Error)) {
+	if err == nil {
+		return
+	}
+	if e, ok := err.(*Error); ok {
+		fn(e)
+	}
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		walkErrors(u.Unwrap(), fn)
+	case interface{ Unwrap() []error }:
+		for _, err := range u.Unwrap() {
+			walkErrors(err, fn)
+		}
+	}
+}
+
+// nodePath returns the path to the outermost node in n positioned at line and column.
+func nodePath(n *yaml.Node, line, column int) (string, bool) {
+	if n == nil {
+		return "", false
+	}
+	if n.Kind == yaml.DocumentNode {
+		for _, content := range n.Content {
+			if path, ok := nodePath(content, line, column); ok {
+				return path, true
+			}
+		}
+		return "", false
+	}
+	if n.Line == line && n.Column == column {
+		return "", true
+	}
+
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i]
+			if key.Line == line && key.Column == column {
+				return key.Value, true
+			}
+			if path, ok := nodePath(n.Content[i+1], line, column); ok {
+				return joinPath(key.Value, path), true
+			}
+		}
+	case yaml.SequenceNode:
+		for i, item := range n.Content {
+			if path, ok := nodePath(item, line, column); ok {
+				return joinPath(fmt.Sprintf("[%d]", i), path), true
+			}
+		}
+	}
+	return "", false
+}
+
+func joinPath(head, tail string) string {
+	switch {
+	case tail == "":
+		return head
+	case strings.HasPrefix(tail, "["):
+		return head + tail
+	default:
+		return head + "." + tail
+	}
+}
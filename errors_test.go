@@ -0,0 +1,111 @@
+package confection_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/raphaelreyna/confection"
+	"gopkg.in/yaml.v3"
+)
+
+type listenersConfig struct {
+	Listeners []struct {
+		Filters []confection.TypedConfig `yaml:"filters"`
+	} `yaml:"listeners"`
+}
+
+func TestError_UnknownTypePath(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+
+	input := `
+listeners:
+- filters:
+  - name: first
+    typed_config:
+      "@type": greetings.english
+  - name: second
+    typed_config:
+      "@type": greetings.french
+      greeting: Bonjour
+`
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(input), &root); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	var cfg listenersConfig
+	if err := root.Decode(&cfg); err != nil {
+		t.Fatalf("decode: %s", err)
+	}
+
+	_, err := confection.Make[Greeter](c, cfg.Listeners[0].Filters[1])
+	err = confection.ResolvePath(&root, err)
+
+	if !errors.Is(err, confection.ErrUnknownType) {
+		t.Fatalf("expected ErrUnknownType, got %v", err)
+	}
+	var e *confection.Error
+	if !errors.As(err, &e) {
+		t.Fatalf("expected *confection.Error, got %T", err)
+	}
+	if e.Line == 0 {
+		t.Error("expected line number in error")
+	}
+	if e.Path != "listeners[0].filters[1].typed_config" {
+		t.Errorf("unexpected path: %q", e.Path)
+	}
+	if e.Interface != "confection_test.Greeter" || e.Type != "greetings.french" || e.Name != "second" {
+		t.Errorf("unexpected error fields: %+v", e)
+	}
+}
+
+func TestError_MissingTypePath(t *testing.T) {
+	input := `
+greeting:
+  name: english
+  typed_config:
+    greeting: Hi
+`
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(input), &root); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	var cfg struct {
+		Greeting confection.TypedConfig `yaml:"greeting"`
+	}
+	err := confection.ResolvePath(&root, root.Decode(&cfg))
+
+	if !errors.Is(err, confection.ErrMissingType) {
+		t.Fatalf("expected ErrMissingType, got %v", err)
+	}
+	var e *confection.Error
+	if !errors.As(err, &e) {
+		t.Fatalf("expected *confection.Error, got %T", err)
+	}
+	if e.Path != "greeting.typed_config" {
+		t.Errorf("unexpected path: %q", e.Path)
+	}
+	if e.Name != "english" {
+		t.Errorf("expected name 'english', got %q", e.Name)
+	}
+}
+
+func TestError_InterfaceNotRegistered(t *testing.T) {
+	c := confection.NewConfection()
+
+	input := `
+name: english
+typed_config:
+  "@type": greetings.english
+`
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+
+	_, err := confection.Make[Greeter](c, tc)
+	if !errors.Is(err, confection.ErrInterfaceNotRegistered) {
+		t.Fatalf("expected ErrInterfaceNotRegistered, got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package confection
+
+import (
+	"reflect"
+	"strings"
+)
+
+// yamlField is a struct field as seen by the yaml decoder.
+type yamlField struct {
+	Name      string
+	Type      reflect.Type
+	OmitEmpty bool
+}
+
+// yamlFields returns the fields yaml.v3 decodes into for struct type t,
+// flattening inline structs. The boolean reports whether t has an inline map,
+// in which case any key is accepted.
+func yamlFields(t reflect.Type) ([]yamlField, bool) {
+	var (
+		fields    []yamlField
+		inlineMap bool
+	)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("yaml")
+		if tag == "" && !strings.Contains(string(field.Tag), ":") {
+			tag = string(field.Tag)
+		}
+		if tag == "-" {
+			continue
+		}
+
+		name, flags, _ := strings.Cut(tag, ",")
+		var omitEmpty, inline bool
+		for _, flag := range strings.Split(flags, ",") {
+			switch flag {
+			case "omitempty":
+				omitEmpty = true
+			case "inline":
+				inline = true
+			}
+		}
+
+		if inline {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			switch ft.Kind() {
+			case reflect.Map:
+				inlineMap = true
+			case reflect.Struct:
+				inner, innerMap := yamlFields(ft)
+				fields = append(fields, inner...)
+				inlineMap = inlineMap || innerMap
+			}
+			continue
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields = append(fields, yamlField{
+			Name:      name,
+			Type:      field.Type,
+			OmitEmpty: omitEmpty,
+		})
+	}
+	return fields, inlineMap
+}
@@ -0,0 +1,208 @@
+package confection
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultTypeKey is the discriminator key used unless WithTypeKey is given.
+const DefaultTypeKey = "@type"
+
+// Shape is the layout of a TypedConfig block in a document.
+type Shape int
+
+const (
+	// EnvelopeShape nests the config in a typed_config block next to its name,
+	// with the discriminator inside the block. This is the default.
+	//
+	//	name: my-thing
+	//	typed_config:
+	//	  "@type": some.type.name
+	//	  key: value
+	EnvelopeShape Shape = iota
+	// FlatShape puts the discriminator next to the config's own fields.
+	// A name key, if present, sets TypedConfig.Name and is left in the config.
+	//
+	//	name: my-thing
+	//	type: some.type.name
+	//	key: value
+	FlatShape
+	// KubernetesShape selects the factory by the apiVersion and kind fields
+	// together, as Kubernetes resources do, and reads the name from
+	// metadata.name. The config is the whole document without apiVersion and
+	// kind. Register factories for it with RegisterKind.
+	//
+	//	apiVersion: example.com/v1
+	//	kind: Widget
+	//	metadata:
+	//	  name: my-thing
+	//	spec:
+	//	  key: value
+	KubernetesShape
+)
+
+func (s Shape) String() string {
+	switch s {
+	case EnvelopeShape:
+		return "envelope"
+	case FlatShape:
+		return "flat"
+	case KubernetesShape:
+		return "kubernetes"
+	default:
+		return fmt.Sprintf("Shape(%d)", int(s))
+	}
+}
+
+// format describes how TypedConfig blocks are laid out in a document.
+type format struct {
+	typeKey string
+	shape   Shape
+}
+
+var defaultFormat = format{typeKey: DefaultTypeKey, shape: EnvelopeShape}
+
+// block names the mapping that holds the discriminator, for error messages.
+func (f format) block() string {
+	switch f.shape {
+	case EnvelopeShape:
+		return "typed_config"
+	case KubernetesShape:
+		return "document"
+	default:
+		return "config"
+	}
+}
+
+// keys returns the keys that together select the factory.
+func (f format) keys() []string {
+	switch {
+	case f.shape == KubernetesShape:
+		return []string{"apiVersion", "kind"}
+	case f.typeKey == "":
+		return []string{DefaultTypeKey}
+	default:
+		return []string{f.typeKey}
+	}
+}
+
+// ownKeys returns the keys the shape leaves in the config block next to the
+// config's own, such as the name of a flat config.
+func (f format) ownKeys() []string {
+	switch f.shape {
+	case FlatShape:
+		return []string{"name"}
+	case KubernetesShape:
+		return []string{"metadata"}
+	default:
+		return nil
+	}
+}
+
+// typeName returns the registered type name selected by the values of keys.
+func (f format) typeName(values []string) string {
+	if f.shape == KubernetesShape {
+		return ParseGroupVersionKind(values[0], values[1]).TypeName()
+	}
+	return values[0]
+}
+
+// values is the inverse of typeName.
+func (f format) values(typeName string) []string {
+	if f.shape == KubernetesShape {
+		apiVersion, kind := typeName, ""
+		if i := strings.LastIndexByte(typeName, '/'); i != -1 {
+			apiVersion, kind = typeName[:i], typeName[i+1:]
+		}
+		return []string{apiVersion, kind}
+	}
+	return []string{typeName}
+}
+
+// WithTypeKey sets the key holding the discriminator, such as "type" or "kind".
+// The default is "@type".
+//
+// TypedConfig values are parsed as they are unmarshalled, before any registry
+// is known, so they are read with the format of the global registry. Set
+// Global to a Confection created with this option when documents use it;
+// Make and Validate re-read configs with their registry's format if it differs.
+func WithTypeKey(key string) Option {
+	return func(c *Confection) {
+		if key != "" {
+			c.format.typeKey = key
+		}
+	}
+}
+
+// WithShape sets the layout of TypedConfig blocks. The default is EnvelopeShape.
+// See WithTypeKey for how formats apply to unmarshalling.
+func WithShape(shape Shape) Option {
+	return func(c *Confection) {
+		c.format.shape = shape
+	}
+}
+
+// missingTypeError reports a config without a discriminator. It matches
+// ErrMissingType while naming the configured keys.
+type missingTypeError struct {
+	keys  []string
+	block string
+}
+
+func (e missingTypeError) Error() string {
+	return fmt.Sprintf("%s not found in %s", strings.Join(e.keys, " and "), e.block)
+}
+
+func (e missingTypeError) Is(target error) bool {
+	return target == ErrMissingType
+}
+
+// GroupVersionKind identifies a Kubernetes-style type by its API group,
+// version and kind. Factories for KubernetesShape documents are registered
+// under its TypeName.
+type GroupVersionKind struct {
+	Group   string
+	Version string
+	Kind    string
+}
+
+// ParseGroupVersionKind builds a GroupVersionKind from the apiVersion and kind
+// fields of a document. An apiVersion without a slash, such as "v1", is a
+// version in the core (empty) group.
+func ParseGroupVersionKind(apiVersion, kind string) GroupVersionKind {
+	group, version, ok := strings.Cut(apiVersion, "/")
+	if !ok {
+		group, version = "", apiVersion
+	}
+	return GroupVersionKind{Group: group, Version: version, Kind: kind}
+}
+
+// APIVersion returns the apiVersion field value, group/version or just version.
+func (gvk GroupVersionKind) APIVersion() string {
+	if gvk.Group == "" {
+		return gvk.Version
+	}
+	return gvk.Group + "/" + gvk.Version
+}
+
+// TypeName returns the name factories for gvk are registered under,
+// apiVersion/kind.
+func (gvk GroupVersionKind) TypeName() string {
+	return gvk.APIVersion() + "/" + gvk.Kind
+}
+
+func (gvk GroupVersionKind) String() string {
+	return gvk.APIVersion() + ", Kind=" + gvk.Kind
+}
+
+// RegisterKind registers factory for KubernetesShape documents of the given
+// group, version and kind. It is RegisterFactory with gvk.TypeName() as the
+// type name. Pass nil for c to use the global registry.
+func RegisterKind[Configuration any, Implementation any](c *Confection, gvk GroupVersionKind, factory Factory[Configuration, Implementation]) {
+	RegisterFactory(c, gvk.TypeName(), factory)
+}
+
+// TryRegisterKind is like RegisterKind but returns an error instead of panicking.
+func TryRegisterKind[Configuration any, Implementation any](c *Confection, gvk GroupVersionKind, factory Factory[Configuration, Implementation]) error {
+	return TryRegisterFactory(c, gvk.TypeName(), factory)
+}
@@ -0,0 +1,134 @@
+package confection_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/raphaelreyna/confection"
+	"gopkg.in/yaml.v3"
+)
+
+// useGlobal installs c as the global registry for the duration of the test.
+func useGlobal(t *testing.T, c *confection.Confection) {
+	t.Helper()
+	confection.Interfaces(nil) // initialize Global so that prev is restored as is
+	prev := confection.Global
+	confection.Global = c
+	t.Cleanup(func() { confection.Global = prev })
+}
+
+func TestFormat_FlatShape(t *testing.T) {
+	c := confection.NewConfection(confection.WithShape(confection.FlatShape), confection.WithTypeKey("type"))
+	useGlobal(t, c)
+	confection.RegisterInterface[Greeter](nil)
+	confection.RegisterFactory(nil, "greetings.english", EnglishFactory)
+
+	input := `
+filters:
+- name: first
+  type: greetings.english
+  greeting: Hello
+- type: greetings.english
+`
+	var cfg struct {
+		Filters []confection.TypedConfig `yaml:"filters"`
+	}
+	if err := yaml.Unmarshal([]byte(input), &cfg); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+
+	if cfg.Filters[0].Name != "first" || cfg.Filters[0].Type() != "greetings.english" {
+		t.Errorf("unexpected TypedConfig: %s", cfg.Filters[0].String())
+	}
+	for i, want := range []string{"Hello", "Hello"} {
+		g, err := confection.Make[Greeter](nil, cfg.Filters[i])
+		if err != nil {
+			t.Fatalf("filter[%d]: Make: %s", i, err)
+		}
+		if got := g.Greet(); got != want {
+			t.Errorf("filter[%d]: expected %q, got %q", i, want, got)
+		}
+	}
+
+	out, err := yaml.Marshal(cfg.Filters[0])
+	if err != nil {
+		t.Fatalf("marshal: %s", err)
+	}
+	if want := "name: first\ntype: greetings.english\ngreeting: Hello\n"; string(out) != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestFormat_CustomTypeKey(t *testing.T) {
+	c := confection.NewConfection(confection.WithTypeKey("kind"))
+	useGlobal(t, c)
+	confection.RegisterInterface[Greeter](nil)
+	confection.RegisterFactory(nil, "greetings.english", EnglishFactory)
+
+	input := `
+name: english
+typed_config:
+  kind: greetings.english
+  greeting: Howdy
+`
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	g, err := confection.Make[Greeter](nil, tc)
+	if err != nil {
+		t.Fatalf("Make: %s", err)
+	}
+	if got := g.Greet(); got != "Howdy" {
+		t.Errorf("expected 'Howdy', got %q", got)
+	}
+
+	schema, err := confection.JSONSchema[Greeter](nil)
+	if err != nil {
+		t.Fatalf("JSONSchema: %s", err)
+	}
+	branch := schema.Properties["typed_config"].OneOf[0]
+	if branch.Properties["kind"] == nil || branch.Properties["kind"].Const != "greetings.english" {
+		t.Errorf("expected schema to pin the kind key, got %+v", branch.Properties)
+	}
+}
+
+func TestFormat_MissingCustomTypeKey(t *testing.T) {
+	useGlobal(t, confection.NewConfection(confection.WithShape(confection.FlatShape), confection.WithTypeKey("kind")))
+
+	var tc confection.TypedConfig
+	err := yaml.Unmarshal([]byte("name: english\ngreeting: Hi\n"), &tc)
+	if !errors.Is(err, confection.ErrMissingType) {
+		t.Fatalf("expected ErrMissingType, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "kind not found") {
+		t.Errorf("expected error to name the key, got: %s", err)
+	}
+}
+
+func TestFormat_MakeRereadsWithRegistryFormat(t *testing.T) {
+	c := confection.NewConfection(confection.WithTypeKey("kind"))
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+
+	// parsed with the global format, which reads @type
+	input := `
+name: english
+typed_config:
+  "@type": legacy.english
+  kind: greetings.english
+  greeting: Hey
+`
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	if tc.Type() != "legacy.english" {
+		t.Fatalf("expected global format to read @type, got %q", tc.Type())
+	}
+
+	if err := confection.Validate[Greeter](c, tc); err != nil {
+		t.Fatalf("expected registry format to read kind, got %s", err)
+	}
+}
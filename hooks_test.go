@@ -0,0 +1,105 @@
+package confection_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/raphaelreyna/confection"
+	"gopkg.in/yaml.v3"
+)
+
+var errEmptyName = errors.New("name must not be empty")
+
+type PoliteConfig struct {
+	Name     string `yaml:"name"`
+	Greeting string `yaml:"greeting"`
+}
+
+func (p *PoliteConfig) SetDefaults() {
+	if p.Greeting == "" {
+		p.Greeting = "Good day"
+	}
+}
+
+func (p PoliteConfig) Validate() error {
+	if p.Name == "" {
+		return errEmptyName
+	}
+	return nil
+}
+
+type Polite struct {
+	Greeter
+	phrase string
+}
+
+func (p *Polite) Greet() string { return p.phrase }
+
+func PoliteFactory(_ context.Context, cfg PoliteConfig) (*Polite, error) {
+	return &Polite{phrase: cfg.Greeting + ", " + cfg.Name}, nil
+}
+
+func TestHooks_DefaultsApplied(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.polite", PoliteFactory)
+
+	input := `
+name: polite
+typed_config:
+  "@type": greetings.polite
+  name: Sir
+`
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+
+	g, err := confection.Make[Greeter](c, tc)
+	if err != nil {
+		t.Fatalf("Make: %s", err)
+	}
+	if got := g.Greet(); got != "Good day, Sir" {
+		t.Errorf("expected 'Good day, Sir', got %q", got)
+	}
+}
+
+func TestHooks_ValidationFails(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+
+	var calls int
+	confection.RegisterFactory(c, "greetings.polite", func(ctx context.Context, cfg PoliteConfig) (*Polite, error) {
+		calls++
+		return PoliteFactory(ctx, cfg)
+	})
+
+	input := `
+name: polite
+typed_config:
+  "@type": greetings.polite
+  greeting: Hello
+`
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+
+	for name, err := range map[string]error{
+		"Make":     func() error { _, err := confection.Make[Greeter](c, tc); return err }(),
+		"Validate": confection.Validate[Greeter](c, tc),
+	} {
+		if !errors.Is(err, errEmptyName) {
+			t.Errorf("%s: expected validation error, got %v", name, err)
+			continue
+		}
+		var e *confection.Error
+		if !errors.As(err, &e) || e.Line == 0 {
+			t.Errorf("%s: expected *confection.Error with a line number, got %v", name, err)
+		}
+	}
+	if calls != 0 {
+		t.Errorf("expected factory not to be called, got %d calls", calls)
+	}
+}
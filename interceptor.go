@@ -0,0 +1,97 @@
+package confection
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// FactoryCall describes a factory call made by MakeCtx.
+type FactoryCall struct {
+	// Interface is the name of the Interface being constructed.
+	Interface string
+	// Type is the @type of the TypedConfig, as written in the config.
+	Type string
+	// Name is the name of the TypedConfig, if any.
+	Name string
+	// Config is the decoded, converted and validated configuration that will
+	// be passed to the factory. An Interceptor may replace it with another
+	// value of the same type.
+	Config any
+	// Line and Column locate the TypedConfig's typed_config block.
+	Line   int
+	Column int
+}
+
+// Invoker calls the factory for call, or the next Interceptor in the chain.
+type Invoker func(ctx context.Context, call *FactoryCall) (any, error)
+
+// Interceptor wraps factory calls made by MakeCtx. It calls next to continue
+// the call and receives the Implementation the factory returned, which it may
+// inspect or replace; it may also return without calling next. Interceptors
+// run after the config is decoded and before the result is checked against
+// the Interface, and their errors are returned from MakeCtx as *Error.
+//
+// Interceptors suit uniform logging, metrics, panic recovery and auditing:
+//
+//	confection.RegisterInterceptor(nil, func(ctx context.Context, call *confection.FactoryCall, next confection.Invoker) (any, error) {
+//		start := time.Now()
+//		impl, err := next(ctx, call)
+//		log.Printf("built %s %q in %s", call.Interface, call.Type, time.Since(start))
+//		return impl, err
+//	})
+type Interceptor func(ctx context.Context, call *FactoryCall, next Invoker) (any, error)
+
+// RegisterInterceptor installs i around every factory call made with c. The
+// first Interceptor registered is outermost, and those of a parent registry
+// wrap those of its children. Pass nil for c to use the global registry.
+// Panics with ErrSealed if c is sealed.
+func RegisterInterceptor(c *Confection, i Interceptor) {
+	if err := TryRegisterInterceptor(c, i); err != nil {
+		panic(err)
+	}
+}
+
+// TryRegisterInterceptor is like RegisterInterceptor but returns an error
+// wrapping ErrSealed instead of panicking.
+func TryRegisterInterceptor(c *Confection, i Interceptor) error {
+	conf := getConfection(c)
+
+	unlock := conf.lock()
+	defer unlock()
+
+	if conf.sealed {
+		return fmt.Errorf("unable to register interceptor: %w", ErrSealed)
+	}
+	conf.interceptors = append(conf.interceptors, i)
+
+	return nil
+}
+
+// invoke calls reg's factory for call through c's Interceptors.
+func (c *Confection) invoke(ctx context.Context, reg *registration, call *FactoryCall) (any, error) {
+	next := Invoker(func(ctx context.Context, call *FactoryCall) (any, error) {
+		return reg.build(ctx, call.Config)
+	})
+	interceptors := c.interceptorsFor()
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, inner := interceptors[i], next
+		next = func(ctx context.Context, call *FactoryCall) (any, error) {
+			return interceptor(ctx, call, inner)
+		}
+	}
+	return next(ctx, call)
+}
+
+// interceptorsFor returns the Interceptors for factory calls made with c,
+// outermost first.
+func (c *Confection) interceptorsFor() []Interceptor {
+	unlock := c.rlock()
+	defer unlock()
+
+	var interceptors []Interceptor
+	for _, r := range slices.Backward(c.lineage()) {
+		interceptors = append(interceptors, r.interceptors...)
+	}
+	return interceptors
+}
@@ -0,0 +1,145 @@
+package confection_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/raphaelreyna/confection"
+	"gopkg.in/yaml.v3"
+)
+
+func recordingInterceptor(name string, order *[]string) confection.Interceptor {
+	return func(ctx context.Context, call *confection.FactoryCall, next confection.Invoker) (any, error) {
+		*order = append(*order, name+" before")
+		impl, err := next(ctx, call)
+		*order = append(*order, name+" after")
+		return impl, err
+	}
+}
+
+func TestInterceptor_ReceivesCall(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+
+	var got confection.FactoryCall
+	var result any
+	confection.RegisterInterceptor(c, func(ctx context.Context, call *confection.FactoryCall, next confection.Invoker) (any, error) {
+		got = *call
+		impl, err := next(ctx, call)
+		result = impl
+		return impl, err
+	})
+
+	input := `
+name: hello
+typed_config:
+  "@type": greetings.english
+  greeting: Howdy
+`
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	g, err := confection.Make[Greeter](c, tc)
+	if err != nil {
+		t.Fatalf("Make: %s", err)
+	}
+
+	if got.Interface != "confection_test.Greeter" || got.Type != "greetings.english" || got.Name != "hello" || got.Line != 4 {
+		t.Errorf("unexpected call: %+v", got)
+	}
+	if cfg, ok := got.Config.(*EnglishConfig); !ok || cfg.Greeting != "Howdy" {
+		t.Errorf("expected the decoded config, got %#v", got.Config)
+	}
+	if result != g {
+		t.Errorf("expected the interceptor to see the result")
+	}
+}
+
+func TestInterceptor_Order(t *testing.T) {
+	var order []string
+	parent := confection.NewConfection()
+	confection.RegisterInterface[Greeter](parent)
+	confection.RegisterFactory(parent, "greetings.english", EnglishFactory)
+	confection.RegisterInterceptor(parent, recordingInterceptor("parent", &order))
+
+	child := confection.NewConfection(confection.WithParent(parent))
+	confection.RegisterInterceptor(child, recordingInterceptor("first", &order))
+	confection.RegisterInterceptor(child, recordingInterceptor("second", &order))
+
+	greet(t, child, "greetings.english")
+
+	want := "parent before,first before,second before,second after,first after,parent after"
+	if got := strings.Join(order, ","); got != want {
+		t.Errorf("unexpected order:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestInterceptor_ReplacesConfigAndResult(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+	confection.RegisterInterceptor(c, func(ctx context.Context, call *confection.FactoryCall, next confection.Invoker) (any, error) {
+		call.Config = &EnglishConfig{Greeting: "Intercepted"}
+		return next(ctx, call)
+	})
+
+	if got := greet(t, c, "greetings.english"); got != "Intercepted" {
+		t.Errorf("expected the replaced config to reach the factory, got %q", got)
+	}
+
+	c = confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+	confection.RegisterInterceptor(c, func(context.Context, *confection.FactoryCall, confection.Invoker) (any, error) {
+		return &English{phrase: "short-circuit"}, nil
+	})
+	if got := greet(t, c, "greetings.english"); got != "short-circuit" {
+		t.Errorf("expected the interceptor's result, got %q", got)
+	}
+}
+
+func TestInterceptor_Errors(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.english", func(context.Context, *EnglishConfig) (*English, error) {
+		panic("boom")
+	})
+	confection.RegisterFactory(c, "greetings.retyped", EnglishFactory)
+	confection.RegisterInterceptor(c, func(ctx context.Context, call *confection.FactoryCall, next confection.Invoker) (impl any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("factory panicked: %v", r)
+			}
+		}()
+		if call.Type == "greetings.retyped" {
+			call.Config = EnglishConfig{}
+		}
+		return next(ctx, call)
+	})
+
+	for _, tt := range []struct {
+		typeName string
+		want     string
+	}{
+		{"greetings.english", "factory panicked: boom"},
+		{"greetings.retyped", "factory takes *confection_test.EnglishConfig"},
+	} {
+		tc, err := confection.NewTypedConfig("", tt.typeName, nil)
+		if err != nil {
+			t.Fatalf("NewTypedConfig: %s", err)
+		}
+		_, err = confection.Make[Greeter](c, tc)
+		var e *confection.Error
+		if !errors.As(err, &e) || e.Type != tt.typeName {
+			t.Fatalf("%s: expected *Error, got %v", tt.typeName, err)
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error to contain %q, got %q", tt.typeName, tt.want, err)
+		}
+	}
+}
@@ -12,3 +12,17 @@ type Interface interface {
 }
 
 var _interfaceType = reflect.TypeOf((*Interface)(nil)).Elem()
+
+// Defaulter is implemented by factory configurations that fill in default values.
+// SetDefaults is called after the typed_config block is decoded and before the
+// factory is called, so it should only set fields that were left empty.
+type Defaulter interface {
+	SetDefaults()
+}
+
+// Validator is implemented by factory configurations that check their own values.
+// Validate is called after SetDefaults and before the factory is called;
+// a non-nil error aborts construction.
+type Validator interface {
+	Validate() error
+}
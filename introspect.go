@@ -0,0 +1,108 @@
+package confection
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// FactoryInfo describes a factory registered for an Interface.
+type FactoryInfo struct {
+	// Interface is the name of the Interface the factory is registered for.
+	Interface string
+	// Type is the @type name the factory is bound to.
+	Type string
+	// Config is the factory's Configuration type.
+	Config reflect.Type
+	// Implementation is the factory's Implementation type.
+	Implementation reflect.Type
+}
+
+// InterfaceInfo describes a registered Interface and the factories bound to it.
+type InterfaceInfo struct {
+	// Name is the name the Interface is registered under.
+	Name string
+	// Type is the Interface's Go type.
+	Type reflect.Type
+	// Factories lists the factories registered for the Interface, sorted by Type.
+	Factories []FactoryInfo
+}
+
+// Interfaces returns every Interface registered with c, sorted by name.
+// A child registry reports its ancestors' registrations too.
+// Pass nil to use the global registry.
+func Interfaces(c *Confection) []InterfaceInfo {
+	conf := getConfection(c)
+
+	unlock := conf.rlock()
+	defer unlock()
+
+	types := conf.interfaceTypes()
+	infos := make([]InterfaceInfo, 0, len(types))
+	for name, t := range types {
+		infos = append(infos, InterfaceInfo{
+			Name:      name,
+			Type:      t,
+			Factories: conf.factoryInfos(name),
+		})
+	}
+	slices.SortFunc(infos, func(a, b InterfaceInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return infos
+}
+
+// Factories returns the factories registered for interface I, sorted by @type name.
+// Returns nil if I is not registered. Pass nil for c to use the global registry.
+func Factories[I Interface](c *Confection) []FactoryInfo {
+	conf := getConfection(c)
+	name := reflect.TypeFor[I]().String()
+
+	unlock := conf.rlock()
+	defer unlock()
+
+	if _, ok := conf.interfaceType(name); !ok {
+		return nil
+	}
+	return conf.factoryInfos(name)
+}
+
+// LookupFactory returns the factory registered for interface I under typeName.
+// Pass nil for c to use the global registry.
+func LookupFactory[I Interface](c *Confection, typeName string) (FactoryInfo, bool) {
+	conf := getConfection(c)
+	name := reflect.TypeFor[I]().String()
+
+	unlock := conf.rlock()
+	defer unlock()
+
+	reg := conf.factory(name, typeName)
+	if reg == nil {
+		return FactoryInfo{}, false
+	}
+	return reg.info(name, typeName), true
+}
+
+// factoryInfos describes the factories for the named Interface, sorted by
+// @type name. The caller must hold c.rlock.
+func (c *Confection) factoryInfos(name string) []FactoryInfo {
+	regs := c.factories(name)
+	infos := make([]FactoryInfo, 0, len(regs))
+	for typeName, reg := range regs {
+		infos = append(infos, reg.info(name, typeName))
+	}
+	slices.SortFunc(infos, func(a, b FactoryInfo) int {
+		return strings.Compare(a.Type, b.Type)
+	})
+	return infos
+}
+
+func (r *registration) info(interfaceName, typeName string) FactoryInfo {
+	return FactoryInfo{
+		Interface:      interfaceName,
+		Type:           typeName,
+		Config:         r.configType,
+		Implementation: r.implType,
+	}
+}
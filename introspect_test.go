@@ -0,0 +1,92 @@
+package confection_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/raphaelreyna/confection"
+)
+
+type SpanishConfig struct {
+	Formal bool `yaml:"use_formal"`
+}
+
+type Spanish struct {
+	Greeter
+	phrase string
+}
+
+func (s *Spanish) Greet() string { return s.phrase }
+
+func SpanishFactory(_ context.Context, cfg SpanishConfig) (*Spanish, error) {
+	if cfg.Formal {
+		return &Spanish{phrase: "Hola, ¿cómo está usted?"}, nil
+	}
+	return &Spanish{phrase: "Hola, ¿cómo estás?"}, nil
+}
+
+func TestInterfaces(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterInterface[Wrapper](c)
+	confection.RegisterFactory(c, "greetings.spanish", SpanishFactory)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+
+	infos := confection.Interfaces(c)
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 interfaces, got %d", len(infos))
+	}
+
+	greeter := infos[0]
+	if greeter.Name != "confection_test.Greeter" {
+		t.Errorf("expected first interface 'confection_test.Greeter', got %q", greeter.Name)
+	}
+	if greeter.Type != reflect.TypeFor[Greeter]() {
+		t.Errorf("expected Greeter type, got %v", greeter.Type)
+	}
+	if len(greeter.Factories) != 2 {
+		t.Fatalf("expected 2 factories, got %d", len(greeter.Factories))
+	}
+	if greeter.Factories[0].Type != "greetings.english" || greeter.Factories[1].Type != "greetings.spanish" {
+		t.Errorf("expected factories sorted by type, got %q, %q", greeter.Factories[0].Type, greeter.Factories[1].Type)
+	}
+
+	wrapper := infos[1]
+	if wrapper.Name != "confection_test.Wrapper" {
+		t.Errorf("expected second interface 'confection_test.Wrapper', got %q", wrapper.Name)
+	}
+	if len(wrapper.Factories) != 0 {
+		t.Errorf("expected no factories for Wrapper, got %d", len(wrapper.Factories))
+	}
+}
+
+func TestLookupFactory(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+
+	info, ok := confection.LookupFactory[Greeter](c, "greetings.english")
+	if !ok {
+		t.Fatal("expected factory to be found")
+	}
+	if info.Interface != "confection_test.Greeter" {
+		t.Errorf("unexpected interface: %q", info.Interface)
+	}
+	if info.Config != reflect.TypeFor[*EnglishConfig]() {
+		t.Errorf("expected config type *EnglishConfig, got %v", info.Config)
+	}
+	if info.Implementation != reflect.TypeFor[*English]() {
+		t.Errorf("expected implementation type *English, got %v", info.Implementation)
+	}
+
+	if _, ok := confection.LookupFactory[Greeter](c, "greetings.french"); ok {
+		t.Error("expected unregistered type to not be found")
+	}
+	if _, ok := confection.LookupFactory[Wrapper](c, "greetings.english"); ok {
+		t.Error("expected unregistered interface to not be found")
+	}
+	if got := confection.Factories[Wrapper](c); got != nil {
+		t.Errorf("expected nil factories for unregistered interface, got %v", got)
+	}
+}
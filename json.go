@@ -0,0 +1,273 @@
+package confection
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UnmarshalJSON implements json.Unmarshaler. The JSON form mirrors the YAML form:
+//
+//	{"name": "my-thing", "typed_config": {"@type": "some.type.name", "key": "value"}}
+//
+// The document is converted to a yaml.Node so factories decode JSON and YAML
+// configs identically. encoding/json passes data without the rest of the
+// document, so errors for the TypedConfig carry no Line and Column, and a Path
+// relative to it, such as typed_config. Decode the document with DecodeJSON to
+// locate errors within it.
+func (c *TypedConfig) UnmarshalJSON(data []byte) error {
+	n, err := jsonNode(data)
+	if err != nil {
+		return err
+	}
+	if err := c.UnmarshalYAML(n); err != nil {
+		return (&TypedConfig{raw: n, json: true}).jsonRelative(err)
+	}
+	c.json = true
+
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, writing the same document as
+// MarshalYAML in the shape the TypedConfig was read in.
+func (c TypedConfig) MarshalJSON() ([]byte, error) {
+	n, err := c.MarshalYAML()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, n.(*yaml.Node)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// DecodeJSON decodes the JSON document data into v as yaml.v3 decodes a YAML
+// document: fields are matched by their yaml tags, and TypedConfigs are
+// unmarshalled with UnmarshalYAML. Errors from decoding, and from making the
+// TypedConfigs afterwards, are then positioned within data, with lines and
+// columns counted as in a YAML document. Errors from decoding, including
+// mismatched types, are *Errors with their Path filled in; pass later errors
+// to ResolveJSONPath.
+func DecodeJSON(data []byte, v any) error {
+	n, err := jsonNode(data)
+	if err != nil {
+		return err
+	}
+	return ResolvePath(n, typeErrors(n, n.Decode(v)))
+}
+
+// typeErrors converts the messages of a *yaml.TypeError from decoding root
+// into joined *Errors positioned at the values they are about, so that their
+// paths can be resolved. Other errors are returned as is.
+func typeErrors(root *yaml.Node, err error) error {
+	var te *yaml.TypeError
+	if !errors.As(err, &te) {
+		return err
+	}
+	errs := make([]error, 0, len(te.Errors))
+	for _, msg := range te.Errors {
+		e := &Error{Err: errors.New(msg)}
+		if _, scanErr := fmt.Sscanf(msg, "line %d: ", &e.Line); scanErr == nil {
+			e.Err = errors.New(strings.TrimPrefix(msg, fmt.Sprintf("line %d: ", e.Line)))
+			if n := valueOnLine(root, e.Line, msg); n != nil {
+				e.Column = n.Column
+			}
+		}
+		errs = append(errs, e)
+	}
+	return errors.Join(errs...)
+}
+
+// valueOnLine returns the value in n on line that msg, a yaml.v3 type error,
+// is about: the only one on the line, or the one msg quotes.
+func valueOnLine(n *yaml.Node, line int, msg string) *yaml.Node {
+	var values []*yaml.Node
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		for i, child := range n.Content {
+			if n.Kind == yaml.MappingNode && i%2 == 0 {
+				continue
+			}
+			if child.Line == line {
+				values = append(values, child)
+			}
+			walk(child)
+		}
+	}
+	walk(n)
+
+	if len(values) == 1 {
+		return values[0]
+	}
+	for _, v := range values {
+		quoted := "`" + v.Value + "`"
+		if len(v.Value) > 10 {
+			quoted = "`" + v.Value[:7] + "...`"
+		}
+		if v.Kind == yaml.ScalarNode && strings.Contains(msg, quoted) {
+			return v
+		}
+	}
+	return nil
+}
+
+// ResolveJSONPath is ResolvePath for errors from TypedConfigs decoded from
+// data by DecodeJSON, filling in paths such as filters[1].typed_config. It
+// returns err.
+func ResolveJSONPath(data []byte, err error) error {
+	n, parseErr := jsonNode(data)
+	if parseErr != nil {
+		return err
+	}
+	return ResolvePath(n, err)
+}
+
+// writeJSON writes n to buf as JSON, preserving mapping key order.
+func writeJSON(buf *bytes.Buffer, n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeJSON(buf, n.Content[0])
+	case yaml.AliasNode:
+		return writeJSON(buf, n.Alias)
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, err := json.Marshal(n.Content[i].Value)
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := writeJSON(buf, n.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range n.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		var v any
+		if err := n.Decode(&v); err != nil {
+			return err
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n.Line, err)
+		}
+		buf.Write(data)
+	}
+	return nil
+}
+
+// jsonNode parses a JSON document into a yaml.Node tree. yaml.v3 cannot parse
+// every JSON document (it rejects the \/ escape, for one), so the tree is built
+// from encoding/json tokens. Node positions are lines and columns within data.
+func jsonNode(data []byte) (*yaml.Node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	p := jsonParser{data: data, dec: dec}
+	n, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	line, column := p.position()
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("line %d, column %d: unexpected data after the JSON value", line, column)
+	}
+	return n, nil
+}
+
+type jsonParser struct {
+	data []byte
+	dec  *json.Decoder
+}
+
+// position returns the line and column of the next token.
+func (p *jsonParser) position() (int, int) {
+	off := int(p.dec.InputOffset())
+	for off < len(p.data) && strings.IndexByte(" \t\r\n,:", p.data[off]) != -1 {
+		off++
+	}
+	line := 1 + bytes.Count(p.data[:off], []byte{'\n'})
+	column := off - bytes.LastIndexByte(p.data[:off], '\n')
+	return line, column
+}
+
+func (p *jsonParser) value() (*yaml.Node, error) {
+	line, column := p.position()
+	tok, err := p.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	n := &yaml.Node{Kind: yaml.ScalarNode, Line: line, Column: column}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			n.Kind, n.Tag = yaml.MappingNode, "!!map"
+		} else {
+			n.Kind, n.Tag = yaml.SequenceNode, "!!seq"
+		}
+		for p.dec.More() {
+			if n.Kind == yaml.MappingNode {
+				line, column := p.position()
+				key, err := p.dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				n.Content = append(n.Content, &yaml.Node{
+					Kind:   yaml.ScalarNode,
+					Tag:    "!!str",
+					Value:  key.(string),
+					Line:   line,
+					Column: column,
+				})
+			}
+			item, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, item)
+		}
+		// consume the closing delimiter
+		if _, err := p.dec.Token(); err != nil {
+			return nil, err
+		}
+	case string:
+		n.Tag, n.Value = "!!str", t
+	case json.Number:
+		n.Tag, n.Value = "!!int", t.String()
+		if strings.ContainsAny(n.Value, ".eE") {
+			n.Tag = "!!float"
+		}
+	case bool:
+		n.Tag, n.Value = "!!bool", fmt.Sprint(t)
+	case nil:
+		n.Tag, n.Value = "!!null", "null"
+	}
+
+	return n, nil
+}
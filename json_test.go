@@ -0,0 +1,179 @@
+package confection_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/raphaelreyna/confection"
+)
+
+func TestTypedConfig_UnmarshalJSON(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterInterface[Wrapper](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+
+	input := `{
+  "filters": [
+    {
+      "name": "english",
+      "typed_config": {"@type": "greetings.english", "greeting": "Hi\/there"}
+    }
+  ]
+}`
+	var cfg struct {
+		Filters []confection.TypedConfig `json:"filters"`
+	}
+	if err := json.Unmarshal([]byte(input), &cfg); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+
+	tc := cfg.Filters[0]
+	if tc.Name != "english" || tc.Type() != "greetings.english" {
+		t.Fatalf("unexpected TypedConfig: %s", tc.String())
+	}
+
+	g, err := confection.Make[Greeter](c, tc)
+	if err != nil {
+		t.Fatalf("Make: %s", err)
+	}
+	if got := g.Greet(); got != "Hi/there" {
+		t.Errorf("expected 'Hi/there', got %q", got)
+	}
+}
+
+func TestTypedConfig_UnmarshalJSON_Errors(t *testing.T) {
+	var tc confection.TypedConfig
+	err := json.Unmarshal([]byte(`{"name": "english", "typed_config": {"greeting": "Hi"}}`), &tc)
+	if !errors.Is(err, confection.ErrMissingType) {
+		t.Fatalf("expected ErrMissingType, got %v", err)
+	}
+	var e *confection.Error
+	if !errors.As(err, &e) {
+		t.Fatalf("expected *confection.Error, got %T", err)
+	}
+	if e.Path != "typed_config" || e.Line != 0 {
+		t.Errorf("expected path 'typed_config' and no line, got %q, line %d", e.Path, e.Line)
+	}
+
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	if err := json.Unmarshal([]byte(`{"name": "french", "typed_config": {"@type": "greetings.french"}}`), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	_, err = confection.Make[Greeter](c, tc)
+	if !errors.As(err, &e) || !errors.Is(err, confection.ErrUnknownType) {
+		t.Fatalf("expected *confection.Error wrapping ErrUnknownType, got %v", err)
+	}
+	if e.Path != "typed_config" || e.Line != 0 {
+		t.Errorf("expected path 'typed_config' and no line, got %q, line %d", e.Path, e.Line)
+	}
+	if !strings.HasPrefix(err.Error(), `typed_config: name "french"`) {
+		t.Errorf("expected the error to lead with the path, got %s", err)
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+
+	input := `{
+  "filters": [
+    {"typed_config": {"@type": "greetings.english"}},
+    {
+      "name": "french",
+      "typed_config": {"@type": "greetings.french"}
+    }
+  ]
+}`
+	var cfg struct {
+		Filters []confection.TypedConfig `yaml:"filters"`
+	}
+	if err := confection.DecodeJSON([]byte(input), &cfg); err != nil {
+		t.Fatalf("DecodeJSON: %s", err)
+	}
+	if _, err := confection.Make[Greeter](c, cfg.Filters[0]); err != nil {
+		t.Fatalf("Make: %s", err)
+	}
+
+	_, err := confection.Make[Greeter](c, cfg.Filters[1])
+	err = confection.ResolveJSONPath([]byte(input), err)
+	var e *confection.Error
+	if !errors.As(err, &e) || !errors.Is(err, confection.ErrUnknownType) {
+		t.Fatalf("expected *confection.Error wrapping ErrUnknownType, got %v", err)
+	}
+	if e.Line != 6 || e.Path != "filters[1].typed_config" {
+		t.Errorf("expected line 6 (filters[1].typed_config), got line %d (%s)", e.Line, e.Path)
+	}
+
+	err = confection.DecodeJSON([]byte(`{"filters": [{"typed_config": {"@type": "greetings.english"}}, 42]}`), &cfg)
+	if !errors.As(err, &e) || e.Path != "filters[1]" {
+		t.Errorf("expected a decoding error at filters[1], got %v", err)
+	}
+
+	var typed struct {
+		Listen struct {
+			Host string `yaml:"host"`
+			Port int    `yaml:"port"`
+		} `yaml:"listen"`
+	}
+	err = confection.DecodeJSON([]byte("{\n  \"listen\": {\"host\": \"a\", \"port\": \"eighty\"}\n}"), &typed)
+	if !errors.As(err, &e) || e.Line != 2 || e.Path != "listen.port" {
+		t.Errorf("expected a type error at line 2 (listen.port), got %v", err)
+	}
+	if err != nil && strings.Contains(err.Error(), "yaml:") {
+		t.Errorf("expected the error without the yaml prefix, got %s", err)
+	}
+
+	for _, input := range []string{`{"filters": []} xyz`, `{"filters": []}]`, `{"filters": []} {}`} {
+		if err := confection.DecodeJSON([]byte(input), &cfg); err == nil {
+			t.Errorf("%s: expected an error for data after the document", input)
+		}
+	}
+}
+
+func TestTypedConfig_MarshalJSON(t *testing.T) {
+	input := `{"name":"english","typed_config":{"@type":"greetings.english","greeting":"Hi","count":3,"tags":["a",null,true]}}`
+
+	var tc confection.TypedConfig
+	if err := json.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	out, err := json.Marshal(tc)
+	if err != nil {
+		t.Fatalf("marshal: %s", err)
+	}
+	if string(out) != input {
+		t.Errorf("round trip mismatch:\n got: %s\nwant: %s", out, input)
+	}
+}
+
+func TestTypedConfig_MarshalJSON_Shapes(t *testing.T) {
+	tc, err := confection.NewTypedConfig("english", "greetings.english", &EnglishConfig{Greeting: "Hi"})
+	if err != nil {
+		t.Fatalf("NewTypedConfig: %s", err)
+	}
+	tc.DependsOn = []string{"first"}
+	out, err := json.Marshal(tc)
+	if err != nil {
+		t.Fatalf("marshal: %s", err)
+	}
+	if want := `{"name":"english","typed_config":{"@type":"greetings.english","greeting":"Hi"},"depends_on":["first"]}`; string(out) != want {
+		t.Errorf("unexpected output:\n got: %s\nwant: %s", out, want)
+	}
+
+	useGlobal(t, confection.NewConfection(confection.WithShape(confection.FlatShape)))
+	input := `{"name":"english","depends_on":["first"],"@type":"greetings.english","greeting":"Hi"}`
+	if err := json.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	if out, err = json.Marshal(tc); err != nil {
+		t.Fatalf("marshal: %s", err)
+	}
+	if string(out) != input {
+		t.Errorf("flat round trip mismatch:\n got: %s\nwant: %s", out, input)
+	}
+}
@@ -0,0 +1,241 @@
+package confection_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/raphaelreyna/confection"
+	"gopkg.in/yaml.v3"
+)
+
+type GreeterResourceConfig struct {
+	Spec struct {
+		Greeting string `yaml:"greeting"`
+	} `yaml:"spec"`
+}
+
+func GreeterResourceFactory(_ context.Context, cfg *GreeterResourceConfig) (*English, error) {
+	return &English{phrase: cfg.Spec.Greeting}, nil
+}
+
+var (
+	greeterV1      = confection.GroupVersionKind{Group: "greetings.example.com", Version: "v1", Kind: "Greeter"}
+	greeterV1beta1 = confection.GroupVersionKind{Group: "greetings.example.com", Version: "v1beta1", Kind: "Greeter"}
+)
+
+func kubernetesRegistry(t *testing.T) {
+	t.Helper()
+	useGlobal(t, confection.NewConfection(confection.WithShape(confection.KubernetesShape)))
+	confection.RegisterInterface[Greeter](nil)
+	confection.RegisterKind(nil, greeterV1, GreeterResourceFactory)
+	confection.RegisterKind(nil, greeterV1beta1, func(_ context.Context, cfg *GreeterResourceConfig) (*English, error) {
+		return &English{phrase: "beta " + cfg.Spec.Greeting}, nil
+	})
+}
+
+func TestKubernetes_SelectsByAPIVersionAndKind(t *testing.T) {
+	kubernetesRegistry(t)
+
+	for _, tt := range []struct {
+		apiVersion string
+		want       string
+	}{
+		{"greetings.example.com/v1", "Hello"},
+		{"greetings.example.com/v1beta1", "beta Hello"},
+	} {
+		input := `
+apiVersion: ` + tt.apiVersion + `
+kind: Greeter
+metadata:
+  name: hello
+spec:
+  greeting: Hello
+`
+		var tc confection.TypedConfig
+		if err := yaml.Unmarshal([]byte(input), &tc); err != nil {
+			t.Fatalf("%s: unmarshal: %s", tt.apiVersion, err)
+		}
+		if tc.Name != "hello" {
+			t.Errorf("%s: expected name from metadata.name, got %q", tt.apiVersion, tc.Name)
+		}
+		gvk, ok := tc.GroupVersionKind()
+		if !ok || gvk.APIVersion() != tt.apiVersion || gvk.Kind != "Greeter" {
+			t.Errorf("%s: unexpected GroupVersionKind %v", tt.apiVersion, gvk)
+		}
+
+		g, err := confection.Make[Greeter](nil, tc)
+		if err != nil {
+			t.Fatalf("%s: Make: %s", tt.apiVersion, err)
+		}
+		if got := g.Greet(); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.apiVersion, tt.want, got)
+		}
+	}
+}
+
+func TestKubernetes_MissingKind(t *testing.T) {
+	kubernetesRegistry(t)
+
+	input := `
+apiVersion: greetings.example.com/v1
+spec:
+  greeting: Hello
+`
+	var tc confection.TypedConfig
+	err := yaml.Unmarshal([]byte(input), &tc)
+	if !errors.Is(err, confection.ErrMissingType) {
+		t.Fatalf("expected ErrMissingType, got %v", err)
+	}
+	if want := "kind not found in document"; !strings.Contains(err.Error(), want) {
+		t.Errorf("expected error to mention %q, got %q", want, err)
+	}
+}
+
+func TestKubernetes_UnknownKind(t *testing.T) {
+	kubernetesRegistry(t)
+
+	input := `
+apiVersion: greetings.example.com/v2
+kind: Greeter
+`
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	if _, err := confection.Make[Greeter](nil, tc); !errors.Is(err, confection.ErrUnknownType) {
+		t.Fatalf("expected ErrUnknownType, got %v", err)
+	}
+}
+
+func TestKubernetes_StrictMetadata(t *testing.T) {
+	c := confection.NewConfection(confection.WithShape(confection.KubernetesShape), confection.WithStrictDecoding())
+	useGlobal(t, c)
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterKind(c, greeterV1, GreeterResourceFactory)
+	type labelledConfig struct {
+		Metadata struct {
+			Name string `yaml:"name"`
+		} `yaml:"metadata"`
+		Spec struct {
+			Greeting string `yaml:"greeting"`
+		} `yaml:"spec"`
+	}
+	confection.RegisterKind(c, greeterV1beta1, func(_ context.Context, cfg *labelledConfig) (*English, error) {
+		return &English{phrase: cfg.Metadata.Name}, nil
+	})
+
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(`
+apiVersion: greetings.example.com/v1
+kind: Greeter
+metadata: {name: english, labels: {app: demo}}
+spec: {greeting: Hello}
+`), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	if _, err := confection.Make[Greeter](c, tc); err != nil {
+		t.Fatalf("expected metadata to be allowed next to the config, got %s", err)
+	}
+
+	// a config declaring metadata has it checked like any other field
+	if err := yaml.Unmarshal([]byte(`
+apiVersion: greetings.example.com/v1beta1
+kind: Greeter
+metadata: {name: english, labels: {app: demo}}
+`), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	_, err := confection.Make[Greeter](c, tc)
+	if !errors.Is(err, confection.ErrUnknownField) || !strings.Contains(err.Error(), `"labels"`) {
+		t.Errorf("expected ErrUnknownField for labels, got %v", err)
+	}
+}
+
+func TestKubernetes_MarshalRoundTrip(t *testing.T) {
+	kubernetesRegistry(t)
+
+	input := `apiVersion: greetings.example.com/v1
+kind: Greeter
+metadata:
+  name: hello
+  labels:
+    app: demo
+spec:
+  greeting: Hello
+`
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	if got := marshalYAML(t, tc); got != input {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", got, input)
+	}
+
+	tc.Name = "renamed"
+	want := `apiVersion: greetings.example.com/v1
+kind: Greeter
+metadata:
+  name: renamed
+  labels:
+    app: demo
+spec:
+  greeting: Hello
+`
+	if got := marshalYAML(t, tc); got != want {
+		t.Errorf("unexpected output after rename:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestKubernetes_NewTypedConfig(t *testing.T) {
+	kubernetesRegistry(t)
+
+	cfg := GreeterResourceConfig{}
+	cfg.Spec.Greeting = "Hi"
+	tc, err := confection.NewTypedConfig("hi", greeterV1.TypeName(), cfg)
+	if err != nil {
+		t.Fatalf("NewTypedConfig: %s", err)
+	}
+	want := `apiVersion: greetings.example.com/v1
+kind: Greeter
+spec:
+  greeting: Hi
+metadata:
+  name: hi
+`
+	if got := marshalYAML(t, tc); got != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestKubernetes_Schema(t *testing.T) {
+	kubernetesRegistry(t)
+
+	s, err := confection.JSONSchema[Greeter](nil)
+	if err != nil {
+		t.Fatalf("JSONSchema: %s", err)
+	}
+	if len(s.OneOf) != 2 {
+		t.Fatalf("expected 2 branches, got %d", len(s.OneOf))
+	}
+	for _, branch := range s.OneOf {
+		if branch.Properties["kind"] == nil || branch.Properties["kind"].Const != "Greeter" {
+			t.Errorf("%s: expected kind const Greeter, got %+v", branch.Title, branch.Properties["kind"])
+		}
+		if branch.Properties["apiVersion"] == nil || branch.Properties["metadata"] == nil {
+			t.Errorf("%s: expected apiVersion and metadata properties", branch.Title)
+		}
+	}
+}
+
+func TestParseGroupVersionKind(t *testing.T) {
+	gvk := confection.ParseGroupVersionKind("v1", "ConfigMap")
+	if gvk.Group != "" || gvk.Version != "v1" || gvk.TypeName() != "v1/ConfigMap" {
+		t.Errorf("unexpected core GroupVersionKind %+v", gvk)
+	}
+	gvk = confection.ParseGroupVersionKind("apps/v1", "Deployment")
+	if gvk.Group != "apps" || gvk.APIVersion() != "apps/v1" || gvk.String() != "apps/v1, Kind=Deployment" {
+		t.Errorf("unexpected GroupVersionKind %+v", gvk)
+	}
+}
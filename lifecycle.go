@@ -0,0 +1,142 @@
+package confection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// Starter is implemented by instances that must be started after they are
+// constructed, such as servers and background workers.
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Stopper is implemented by instances that must be stopped on shutdown.
+// Instances that implement io.Closer instead are closed.
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// Lifecycle records the instances MakeCtx constructs with a context carrying
+// it, so they can be started and shut down together:
+//
+//	lc := confection.NewLifecycle()
+//	ctx = confection.WithLifecycle(ctx, lc)
+//	server, err := confection.MakeCtx[Server](ctx, nil, cfg.Server)
+//	...
+//	if err := lc.Start(ctx); err != nil { ... }
+//	defer lc.Shutdown(context.Background())
+//
+// Factories that pass their context on to MakeCtx for nested configs have the
+// nested instances recorded in the same Lifecycle, before the instance that
+// contains them. The instance recorded is the one the factory returned, before
+// any decorators are applied. A Lifecycle is safe for concurrent use.
+type Lifecycle struct {
+	mu        sync.Mutex
+	instances []any
+	// started is the number of instances, from the start of instances, that
+	// Start has started.
+	started int
+}
+
+// NewLifecycle returns an empty Lifecycle.
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{}
+}
+
+type lifecycleKey struct{}
+
+// WithLifecycle returns a copy of ctx that makes MakeCtx record the instances
+// it constructs in l.
+func WithLifecycle(ctx context.Context, l *Lifecycle) context.Context {
+	return context.WithValue(ctx, lifecycleKey{}, l)
+}
+
+// LifecycleFrom returns the Lifecycle carried by ctx, if any.
+func LifecycleFrom(ctx context.Context) (*Lifecycle, bool) {
+	l, ok := ctx.Value(lifecycleKey{}).(*Lifecycle)
+	return l, ok && l != nil
+}
+
+// Instances returns the recorded instances in construction order.
+func (l *Lifecycle) Instances() []any {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return slices.Clone(l.instances)
+}
+
+// Start calls Start on every recorded Starter not yet started, in construction
+// order, and stops at the first error. Instances constructed after Start are
+// started by the next call. On error, call Shutdown to release the instances.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.started == len(l.instances) {
+			l.mu.Unlock()
+			return nil
+		}
+		x := l.instances[l.started]
+		l.started++
+		l.mu.Unlock()
+
+		if s, ok := x.(Starter); ok {
+			if err := s.Start(ctx); err != nil {
+				return fmt.Errorf("starting %T: %w", x, err)
+			}
+		}
+	}
+}
+
+// Shutdown stops every recorded instance in reverse construction order,
+// calling Stop on Stoppers and Close on io.Closers, and forgets them. Every
+// instance is shut down even if some fail; the errors are joined.
+func (l *Lifecycle) Shutdown(ctx context.Context) error {
+	l.mu.Lock()
+	instances := l.instances
+	l.instances, l.started = nil, 0
+	l.mu.Unlock()
+
+	var errs []error
+	for _, x := range slices.Backward(instances) {
+		errs = append(errs, closeInstance(ctx, x))
+	}
+	return errors.Join(errs...)
+}
+
+// add records x, unless it is already recorded.
+func (l *Lifecycle) add(x any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if slices.ContainsFunc(l.instances, func(y any) bool { return same(x, y) }) {
+		return
+	}
+	l.instances = append(l.instances, x)
+}
+
+// same reports whether x and y are the same instance.
+func same(x, y any) bool {
+	t := reflect.TypeOf(x)
+	return t != nil && t == reflect.TypeOf(y) && t.Comparable() && x == y
+}
+
+// closeInstance stops x if it is a Stopper, or closes it if it is an io.Closer.
+func closeInstance(ctx context.Context, x any) error {
+	var err error
+	switch x := x.(type) {
+	case Stopper:
+		err = x.Stop(ctx)
+	case io.Closer:
+		err = x.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("stopping %T: %w", x, err)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package confection_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/raphaelreyna/confection"
+	"gopkg.in/yaml.v3"
+)
+
+// Service is a Greeter with a lifecycle; it records its events in log.
+type Service struct {
+	Greeter
+	name     string
+	log      *[]string
+	startErr error
+	stopErr  error
+}
+
+type ServiceConfig struct {
+	Name      string `yaml:"name"`
+	FailStart bool   `yaml:"fail_start"`
+	FailStop  bool   `yaml:"fail_stop"`
+}
+
+func (s *Service) Greet() string { return s.name }
+
+func (s *Service) Start(context.Context) error {
+	*s.log = append(*s.log, "start "+s.name)
+	return s.startErr
+}
+
+func (s *Service) Stop(context.Context) error {
+	*s.log = append(*s.log, "stop "+s.name)
+	return s.stopErr
+}
+
+// ClosingWrapper is a Wrapper that is closed rather than stopped.
+type ClosingWrapper struct {
+	Wrapper
+	inner Greeter
+	log   *[]string
+}
+
+func (w *ClosingWrapper) Inner() Greeter { return w.inner }
+
+func (w *ClosingWrapper) Close() error {
+	*w.log = append(*w.log, "close wrapper")
+	return nil
+}
+
+func lifecycleRegistry(log *[]string) *confection.Confection {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterInterface[Wrapper](c)
+	confection.RegisterFactory(c, "service", func(_ context.Context, cfg *ServiceConfig) (*Service, error) {
+		s := &Service{name: cfg.Name, log: log}
+		if cfg.FailStart {
+			s.startErr = errors.New("start " + cfg.Name + " failed")
+		}
+		if cfg.FailStop {
+			s.stopErr = errors.New("stop " + cfg.Name + " failed")
+		}
+		return s, nil
+	})
+	confection.RegisterFactory(c, "wrapper", func(ctx context.Context, cfg *WrapperConfig) (*ClosingWrapper, error) {
+		inner, err := confection.MakeCtx[Greeter](ctx, c, cfg.Child)
+		if err != nil {
+			return nil, err
+		}
+		return &ClosingWrapper{inner: inner, log: log}, nil
+	})
+	return c
+}
+
+func makeServices(t *testing.T, ctx context.Context, c *confection.Confection, input string) {
+	t.Helper()
+	var tcs []confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(input), &tcs); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	for _, tc := range tcs {
+		if _, err := confection.MakeCtx[Greeter](ctx, c, tc); err != nil {
+			t.Fatalf("MakeCtx: %s", err)
+		}
+	}
+}
+
+func TestLifecycle_NestedOrder(t *testing.T) {
+	var log []string
+	c := lifecycleRegistry(&log)
+	lc := confection.NewLifecycle()
+	ctx := confection.WithLifecycle(context.Background(), lc)
+
+	input := `
+name: outer
+typed_config:
+  "@type": wrapper
+  child:
+    typed_config:
+      "@type": service
+      name: inner
+`
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	w, err := confection.MakeCtx[Wrapper](ctx, c, tc)
+	if err != nil {
+		t.Fatalf("MakeCtx: %s", err)
+	}
+
+	instances := lc.Instances()
+	if len(instances) != 2 || instances[0] != w.Inner() || instances[1] != w {
+		t.Fatalf("expected the nested instance recorded first, got %v", instances)
+	}
+	if err := lc.Start(ctx); err != nil {
+		t.Fatalf("Start: %s", err)
+	}
+	if err := lc.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %s", err)
+	}
+
+	want := "start inner,close wrapper,stop inner"
+	if got := strings.Join(log, ","); got != want {
+		t.Errorf("unexpected events:\n%s\nwant:\n%s", got, want)
+	}
+	if n := len(lc.Instances()); n != 0 {
+		t.Errorf("expected Shutdown to forget the instances, got %d", n)
+	}
+}
+
+func TestLifecycle_ShutdownJoinsErrors(t *testing.T) {
+	var log []string
+	c := lifecycleRegistry(&log)
+	lc := confection.NewLifecycle()
+	ctx := confection.WithLifecycle(context.Background(), lc)
+
+	makeServices(t, ctx, c, `
+- typed_config: {"@type": service, name: a, fail_stop: true}
+- typed_config: {"@type": service, name: b}
+- typed_config: {"@type": service, name: c, fail_stop: true}
+`)
+
+	err := lc.Shutdown(ctx)
+	for _, want := range []string{"stop a failed", "stop c failed"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got %v", want, err)
+		}
+	}
+	if got := strings.Join(log, ","); got != "stop c,stop b,stop a" {
+		t.Errorf("expected every instance stopped in reverse order, got %s", got)
+	}
+}
+
+func TestLifecycle_StartError(t *testing.T) {
+	var log []string
+	c := lifecycleRegistry(&log)
+	lc := confection.NewLifecycle()
+	ctx := confection.WithLifecycle(context.Background(), lc)
+
+	makeServices(t, ctx, c, `
+- typed_config: {"@type": service, name: a}
+- typed_config: {"@type": service, name: b, fail_start: true}
+- typed_config: {"@type": service, name: c}
+`)
+
+	err := lc.Start(ctx)
+	if err == nil || !strings.Contains(err.Error(), "start b failed") {
+		t.Fatalf("expected the start error, got %v", err)
+	}
+	if got := strings.Join(log, ","); got != "start a,start b" {
+		t.Errorf("expected Start to stop at the first error, got %s", got)
+	}
+
+	// instances built later are started by the next call
+	makeServices(t, ctx, c, `
+- typed_config: {"@type": service, name: d}
+`)
+	log = nil
+	if err := lc.Start(ctx); err != nil {
+		t.Fatalf("Start: %s", err)
+	}
+	if got := strings.Join(log, ","); got != "start c,start d" {
+		t.Errorf("expected only the instances not yet started, got %s", got)
+	}
+}
+
+func TestLifecycle_RecordsBeforeDecoratorErrors(t *testing.T) {
+	var log []string
+	c := lifecycleRegistry(&log)
+	confection.RegisterDecorator(c, func(context.Context, Greeter) (Greeter, error) {
+		return nil, errors.New("rejected")
+	})
+	lc := confection.NewLifecycle()
+	ctx := confection.WithLifecycle(context.Background(), lc)
+
+	tc, err := confection.NewTypedConfig("", "service", &ServiceConfig{Name: "a"})
+	if err != nil {
+		t.Fatalf("NewTypedConfig: %s", err)
+	}
+	if _, err := confection.MakeCtx[Greeter](ctx, c, tc); err == nil {
+		t.Fatal("expected the decorator error")
+	}
+	if err := lc.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %s", err)
+	}
+	if got := strings.Join(log, ","); got != "stop a" {
+		t.Errorf("expected the instance to be released, got %s", got)
+	}
+	if _, ok := confection.LifecycleFrom(context.Background()); ok {
+		t.Error("expected no Lifecycle in a bare context")
+	}
+}
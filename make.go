@@ -2,42 +2,232 @@ package confection
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 )
 
 // MakeCtx constructs an implementation of interface I from the given TypedConfig,
 // using the provided context. Pass nil for c to use the global registry.
+// Errors are returned as *Error.
+// The factory is called without holding the registry lock, so factories may
+// call Make recursively while other goroutines register factories.
+//
+// If ctx carries a Scope, a named TypedConfig is built once and its instance
+// shared with every reference to its name; see Scope.
 func MakeCtx[I Interface](ctx context.Context, c *Confection, tc TypedConfig) (I, error) {
-	conf := getConfection(c)
+	x, err := getConfection(c).make(ctx, reflect.TypeFor[I](), tc)
+	impl, _ := x.(I)
+	return impl, err
+}
 
-	conf.mu.RLock()
-	defer conf.mu.RUnlock()
+// make constructs an implementation of the Interface t from tc, sharing named
+// instances through the Scope in ctx, if any.
+func (c *Confection) make(ctx context.Context, t reflect.Type, tc TypedConfig) (any, error) {
+	interfaceName := t.String()
 
-	var iface I
-	interfaceName := reflect.TypeFor[I]().String()
-	apiObj, ok := conf.interfaces[interfaceName]
-	if !ok {
-		return iface, fmt.Errorf("line %d: interface %s not registered", tc.line, interfaceName)
+	if err := tc.reformat(c.format); err != nil {
+		return nil, err
+	}
+	s, scoped := ScopeFrom(ctx)
+	if tc.ref == "" && (!scoped || tc.Name == "") {
+		return c.construct(ctx, t, tc)
+	}
+	if !scoped {
+		return nil, tc.error(interfaceName, fmt.Errorf("%s %q: %w: no Scope in context", RefKey, tc.ref, ErrUnknownReference))
 	}
 
-	newImplFunc, exists := apiObj.registeredTypes[tc.Type()]
-	if !exists {
-		return iface, fmt.Errorf("line %d: config type %q not registered for interface %s", tc.line, tc.Type(), interfaceName)
+	x, err := s.get(ctx, tc, func(ctx context.Context, tc TypedConfig) (any, error) {
+		return c.construct(ctx, t, tc)
+	})
+	name := tc.Name
+	var e *Error
+	switch {
+	case tc.ref != "":
+		name = tc.ref
+		if err != nil {
+			err = tc.error(interfaceName, fmt.Errorf("%s %q: %w", RefKey, name, err))
+		}
+	case err != nil && !errors.As(err, &e):
+		// errors from building tc itself are already located
+		err = tc.error(interfaceName, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !implements(x, t) {
+		return nil, tc.error(interfaceName, fmt.Errorf("instance %q is %T, which does not implement %s", name, x, interfaceName))
 	}
+	return x, nil
+}
+
+// construct builds tc with the factory registered for its @type under the
+// Interface t.
+func (c *Confection) construct(ctx context.Context, t reflect.Type, tc TypedConfig) (any, error) {
+	interfaceName := t.String()
 
-	newImpl, err := newImplFunc(ctx, tc.TypedConfig)
+	// the factory runs without holding the registry lock so that it may
+	// call Make for nested configs or register factories itself
+	res, err := c.resolve(ctx, interfaceName, &tc)
 	if err != nil {
-		return iface, fmt.Errorf("line %d: %w", tc.line, err)
+		return nil, err
 	}
-	x, ok := newImpl.(I)
-	if !ok {
-		return iface, fmt.Errorf("line %d: factory for %q returned %T, which does not implement %s", tc.line, tc.Type(), newImpl, interfaceName)
+
+	config, err := c.decode(interfaceName, res, &tc)
+	if err != nil {
+		return nil, err
+	}
+	line, column := tc.position()
+	newImpl, err := c.invoke(ctx, res.reg, &FactoryCall{
+		Interface: interfaceName,
+		Type:      tc._type,
+		Name:      tc.Name,
+		Config:    config,
+		Line:      line,
+		Column:    column,
+	})
+	if err != nil {
+		return nil, tc.error(interfaceName, err)
+	}
+	// record the instance before anything else can fail so that Shutdown
+	// releases it either way
+	if l, ok := LifecycleFrom(ctx); ok {
+		l.add(newImpl)
+	}
+	if !implements(newImpl, t) {
+		return nil, tc.error(interfaceName, fmt.Errorf("factory returned %T, which does not implement %s", newImpl, interfaceName))
+	}
+	x, err := c.decorate(ctx, interfaceName, newImpl)
+	if err != nil {
+		return nil, tc.error(interfaceName, err)
+	}
+	if !implements(x, t) {
+		return nil, tc.error(interfaceName, fmt.Errorf("decorator returned %T, which does not implement %s", x, interfaceName))
 	}
 
 	return x, nil
 }
 
+// implements reports whether x implements the Interface t.
+func implements(x any, t reflect.Type) bool {
+	return x != nil && reflect.TypeOf(x).Implements(t)
+}
+
+// resolve looks up the factory for tc's @type under the named Interface,
+// consulting the Interface's resolvers if there is none, and reports the @type
+// to c's WarningHandler if it is deprecated. tc is first re-read with c's
+// format if it was parsed with a different one.
+func (c *Confection) resolve(ctx context.Context, interfaceName string, tc *TypedConfig) (resolution, error) {
+	if err := tc.reformat(c.format); err != nil {
+		return resolution{}, err
+	}
+
+	res, ok := c.lookup(interfaceName, tc.Type())
+	if !ok {
+		return resolution{}, tc.error(interfaceName, ErrInterfaceNotRegistered)
+	}
+	if res.reg == nil {
+		// resolvers may register factories, so they run without the lock
+		for _, r := range c.resolversFor(interfaceName) {
+			if err := r(ctx, c, interfaceName, res.typeName); err != nil && !errors.Is(err, ErrDuplicateType) {
+				return resolution{}, tc.error(interfaceName, fmt.Errorf("resolver: %w", err))
+			}
+			if res, _ = c.lookup(interfaceName, tc.Type()); res.reg != nil {
+				break
+			}
+		}
+	}
+	if res.reg == nil {
+		return resolution{}, tc.error(interfaceName, ErrUnknownType)
+	}
+	if res.deprecation != nil {
+		c.warn(tc.warning(interfaceName, *res.deprecation))
+	}
+	return res, nil
+}
+
+// decode decodes tc's typed_config block into the factory's Configuration
+// type, converting it through the resolved conversions if tc's @type is an
+// older version, and rejecting unknown keys first if c uses strict decoding.
+func (c *Confection) decode(interfaceName string, res resolution, tc *TypedConfig) (any, error) {
+	reg, chain := res.reg, res.chain
+	configType := reg.configType
+	if len(chain) > 0 {
+		configType = chain[0].fromType
+	}
+	if c.strict {
+		if err := checkConfigFields(tc.TypedConfig, configType, tc.format); err != nil {
+			err.Interface = interfaceName
+			err.Type = tc._type
+			err.Name = tc.Name
+			return nil, tc.jsonRelative(err)
+		}
+	}
+
+	var (
+		config any
+		err    error
+	)
+	if len(chain) > 0 {
+		config, err = convertConfig(tc.TypedConfig, chain, reg)
+	} else {
+		config, err = reg.decode(tc.TypedConfig)
+	}
+	if err != nil {
+		return nil, tc.error(interfaceName, err)
+	}
+	return config, nil
+}
+
+// reformat re-reads tc with format f if it was parsed with a different one.
+func (tc *TypedConfig) reformat(f format) error {
+	if tc.raw == nil || tc.format == f {
+		return nil
+	}
+	json := tc.json
+	if err := tc.parse(tc.raw, f); err != nil {
+		return tc.jsonRelative(err)
+	}
+	tc.json = json
+	return nil
+}
+
+// position returns the line and column of tc's typed_config block.
+func (tc *TypedConfig) position() (line, column int) {
+	if tc.TypedConfig != nil {
+		return tc.TypedConfig.Line, tc.TypedConfig.Column
+	}
+	return tc.line, tc.column
+}
+
+// error returns an *Error for tc positioned at its typed_config block.
+func (tc *TypedConfig) error(interfaceName string, err error) *Error {
+	line, column := tc.position()
+	e := &Error{
+		Line:      line,
+		Column:    column,
+		Interface: interfaceName,
+		Type:      tc._type,
+		Name:      tc.Name,
+		Err:       err,
+	}
+	tc.jsonRelative(e)
+	return e
+}
+
+// jsonRelative replaces the positions of the errors in err with their paths
+// within tc if tc was decoded on its own from JSON, where positions are
+// relative to the TypedConfig rather than to the document. It returns err.
+func (tc *TypedConfig) jsonRelative(err error) error {
+	if !tc.json {
+		return err
+	}
+	walkErrors(ResolvePath(tc.raw, err), func(e *Error) {
+		e.Line, e.Column = 0, 0
+	})
+	return err
+}
+
 // Make constructs an implementation of interface I from the given TypedConfig,
 // using context.Background(). Pass nil for c to use the global registry.
 func Make[I Interface](c *Confection, tc TypedConfig) (I, error) {
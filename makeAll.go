@@ -0,0 +1,86 @@
+package confection
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// MakeAllOption configures MakeAll.
+type MakeAllOption func(*makeAllOptions)
+
+type makeAllOptions struct {
+	concurrency int
+}
+
+// WithConcurrency makes MakeAll construct at most n TypedConfigs at a time.
+// n <= 0, the default, means no limit.
+func WithConcurrency(n int) MakeAllOption {
+	return func(o *makeAllOptions) {
+		o.concurrency = n
+	}
+}
+
+// MakeAll constructs an implementation of interface I from each of tcs
+// concurrently, as MakeCtx does, and returns them in the order of tcs. Pass
+// nil for c to use the global registry.
+//
+// Every TypedConfig is constructed even if some fail, and the errors, each an
+// *Error locating its TypedConfig, are joined in the order of tcs. On failure
+// MakeAll shuts down, as Lifecycle.Shutdown does, every instance it built,
+// including those built by factories for nested configs, and returns no
+// implementations. On success the instances are recorded in the Lifecycle
+// carried by ctx, if any.
+func MakeAll[I Interface](ctx context.Context, c *Confection, tcs []TypedConfig, opts ...MakeAllOption) ([]I, error) {
+	conf := getConfection(c)
+	t := reflect.TypeFor[I]()
+
+	var o makeAllOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var sem chan struct{}
+	if o.concurrency > 0 {
+		sem = make(chan struct{}, o.concurrency)
+	}
+
+	// the instances are recorded apart so that a failure releases only them
+	built := NewLifecycle()
+	buildCtx := WithLifecycle(ctx, built)
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]I, len(tcs))
+		errs    = make([]error, len(tcs))
+	)
+	for i, tc := range tcs {
+		if sem != nil {
+			sem <- struct{}{}
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if sem != nil {
+				defer func() { <-sem }()
+			}
+			x, err := conf.make(buildCtx, t, tc)
+			results[i], _ = x.(I)
+			errs[i] = err
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		if shutdownErr := built.Shutdown(context.WithoutCancel(ctx)); shutdownErr != nil {
+			err = errors.Join(err, shutdownErr)
+		}
+		return nil, err
+	}
+	if l, ok := LifecycleFrom(ctx); ok {
+		for _, x := range built.Instances() {
+			l.add(x)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,128 @@
+package confection_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/raphaelreyna/confection"
+)
+
+// closingGreeter is a Greeter around another that is closed rather than
+// stopped.
+type closingGreeter struct {
+	Greeter
+	inner Greeter
+	log   *[]string
+}
+
+func (g *closingGreeter) Greet() string { return g.inner.Greet() }
+
+func (g *closingGreeter) Close() error {
+	*g.log = append(*g.log, "close greeter")
+	return nil
+}
+
+func TestMakeAll_OrderAndConcurrency(t *testing.T) {
+	var log []string
+	c := lifecycleRegistry(&log)
+	var inFlight, peak atomic.Int32
+	confection.RegisterFactory(c, "slow", func(_ context.Context, cfg *ServiceConfig) (*Service, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(5 * time.Millisecond)
+		return &Service{name: cfg.Name, log: &log}, nil
+	})
+
+	var tcs []confection.TypedConfig
+	for _, name := range strings.Split("a b c d e f g h", " ") {
+		tc, err := confection.NewTypedConfig("", "slow", &ServiceConfig{Name: name})
+		if err != nil {
+			t.Fatalf("NewTypedConfig: %s", err)
+		}
+		tcs = append(tcs, tc)
+	}
+
+	lc := confection.NewLifecycle()
+	ctx := confection.WithLifecycle(context.Background(), lc)
+	gs, err := confection.MakeAll[Greeter](ctx, c, tcs, confection.WithConcurrency(3))
+	if err != nil {
+		t.Fatalf("MakeAll: %s", err)
+	}
+
+	var names []string
+	for _, g := range gs {
+		names = append(names, g.Greet())
+	}
+	if got := strings.Join(names, " "); got != "a b c d e f g h" {
+		t.Errorf("expected results in config order, got %s", got)
+	}
+	if p := peak.Load(); p > 3 {
+		t.Errorf("expected at most 3 concurrent factory calls, got %d", p)
+	}
+	if n := len(lc.Instances()); n != len(tcs) {
+		t.Errorf("expected the instances recorded in the Lifecycle, got %d", n)
+	}
+}
+
+func TestMakeAll_Errors(t *testing.T) {
+	var log []string
+	c := lifecycleRegistry(&log)
+	confection.RegisterFactory(c, "broken", func(context.Context, *struct{}) (*Service, error) {
+		return nil, errors.New("warm-up failed")
+	})
+	confection.RegisterFactory(c, "closing", func(ctx context.Context, cfg *WrapperConfig) (*closingGreeter, error) {
+		inner, err := confection.MakeCtx[Greeter](ctx, c, cfg.Child)
+		if err != nil {
+			return nil, err
+		}
+		return &closingGreeter{inner: inner, log: &log}, nil
+	})
+
+	tcs := unmarshalConfigs(t, `
+- name: ok
+  typed_config:
+    "@type": service
+    name: a
+- name: nested
+  typed_config:
+    "@type": closing
+    child:
+      typed_config:
+        "@type": service
+        name: inner
+- name: broken
+  typed_config:
+    "@type": broken
+- name: missing
+  typed_config:
+    "@type": greetings.french
+`)
+	lc := confection.NewLifecycle()
+	ctx := confection.WithLifecycle(context.Background(), lc)
+	gs, err := confection.MakeAll[Greeter](ctx, c, tcs, confection.WithConcurrency(1))
+	if gs != nil {
+		t.Errorf("expected no results, got %v", gs)
+	}
+	if !errors.Is(err, confection.ErrUnknownType) {
+		t.Errorf("expected ErrUnknownType among the errors, got %v", err)
+	}
+	for _, want := range []string{`line 15: name "broken"`, "warm-up failed", `line 18: name "missing"`} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got %v", want, err)
+		}
+	}
+
+	// every instance built is released, the last built first
+	if got := strings.Join(log, ","); got != "close greeter,stop inner,stop a" {
+		t.Errorf("expected the built instances shut down, got %s", got)
+	}
+	if n := len(lc.Instances()); n != 0 {
+		t.Errorf("expected nothing recorded in the Lifecycle, got %d", n)
+	}
+}
@@ -0,0 +1,141 @@
+package confection_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/raphaelreyna/confection"
+	"gopkg.in/yaml.v3"
+)
+
+func marshalYAML(t *testing.T, v any) string {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		t.Fatalf("marshal: %s", err)
+	}
+	return buf.String()
+}
+
+func TestTypedConfig_MarshalYAML_RoundTrip(t *testing.T) {
+	input := `# the greeter
+greeting:
+  name: spanish # the name
+  typed_config:
+    use_formal: true
+    # pick the implementation
+    "@type": greetings.spanish # spanish it is
+    extra: value
+`
+	var cfg struct {
+		Greeting confection.TypedConfig `yaml:"greeting"`
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(input), &root); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	if err := root.Decode(&cfg); err != nil {
+		t.Fatalf("decode: %s", err)
+	}
+
+	if got := marshalYAML(t, cfg); !strings.Contains(got, `"@type": greetings.spanish # spanish it is`) {
+		t.Errorf("expected @type and its comment to be preserved, got:\n%s", got)
+	}
+
+	out := marshalYAML(t, &struct {
+		Greeting confection.TypedConfig `yaml:"greeting"`
+	}{cfg.Greeting})
+	var reloaded struct {
+		Greeting confection.TypedConfig `yaml:"greeting"`
+	}
+	if err := yaml.Unmarshal([]byte(out), &reloaded); err != nil {
+		t.Fatalf("reload: %s\n%s", err, out)
+	}
+	if reloaded.Greeting.Type() != "greetings.spanish" || reloaded.Greeting.Name != "spanish" {
+		t.Errorf("unexpected reloaded config: %s", reloaded.Greeting.String())
+	}
+
+	lines := strings.Split(out, "\n")
+	var keys []string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "    ") && !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			keys = append(keys, strings.SplitN(strings.TrimSpace(line), ":", 2)[0])
+		}
+	}
+	if strings.Join(keys, ",") != `use_formal,"@type",extra` {
+		t.Errorf("expected @type at its original position, got keys %v in:\n%s", keys, out)
+	}
+}
+
+func TestTypedConfig_MarshalYAML_Modified(t *testing.T) {
+	input := `
+name: english
+typed_config:
+  "@type": greetings.english
+  greeting: Hi
+`
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	tc.Name = "renamed"
+	tc.TypedConfig.Content[1].Value = "Howdy"
+
+	out := marshalYAML(t, tc)
+	want := `name: renamed
+typed_config:
+  "@type": greetings.english
+  greeting: Howdy
+`
+	if out != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestNewTypedConfig(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+
+	tc, err := confection.NewTypedConfig("english", "greetings.english", &EnglishConfig{Greeting: "G'day"})
+	if err != nil {
+		t.Fatalf("NewTypedConfig: %s", err)
+	}
+	if tc.Type() != "greetings.english" {
+		t.Errorf("expected type 'greetings.english', got %q", tc.Type())
+	}
+
+	g, err := confection.Make[Greeter](c, tc)
+	if err != nil {
+		t.Fatalf("Make: %s", err)
+	}
+	if got := g.Greet(); got != "G'day" {
+		t.Errorf("expected \"G'day\", got %q", got)
+	}
+
+	want := `name: english
+typed_config:
+  "@type": greetings.english
+  greeting: G'day
+`
+	if out := marshalYAML(t, tc); out != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestNewTypedConfig_NilAndInvalid(t *testing.T) {
+	tc, err := confection.NewTypedConfig("empty", "greetings.english", nil)
+	if err != nil {
+		t.Fatalf("NewTypedConfig: %s", err)
+	}
+	if tc.TypedConfig == nil || tc.TypedConfig.Kind != yaml.MappingNode {
+		t.Errorf("expected empty mapping, got %v", tc.TypedConfig)
+	}
+
+	if _, err := confection.NewTypedConfig("bad", "greetings.english", "not a mapping"); err == nil {
+		t.Error("expected error for config that does not encode to a mapping")
+	}
+}
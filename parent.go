@@ -0,0 +1,187 @@
+package confection
+
+import "reflect"
+
+// WithParent makes the new registry a child of parent. The child sees every
+// Interface, factory, alias, conversion, deprecation and resolver registered
+// with parent and its ancestors, including ones registered later, and its own
+// registrations add to or shadow them without changing parent:
+//
+//	c := confection.NewConfection(confection.WithParent(nil))
+//	confection.RegisterFactory(c, "greetings.english", FakeEnglishFactory)
+//
+// Factories may be registered with the child for Interfaces registered only
+// with an ancestor. The child starts with parent's decoding options, such as
+// WithStrictDecoding, WithTypeKey, WithShape and WithWarningHandler, which the
+// other opts may override. Pass nil to use the global registry as parent.
+func WithParent(parent *Confection) Option {
+	return func(c *Confection) {
+		c.parent = getConfection(parent)
+	}
+}
+
+// lineage returns c followed by its ancestors, nearest first.
+func (c *Confection) lineage() []*Confection {
+	var l []*Confection
+	for ; c != nil; c = c.parent {
+		l = append(l, c)
+	}
+	return l
+}
+
+// rlock read-locks c and its ancestors, and returns a func that unlocks them.
+// Registries are always locked child first, so a registry locked for writing
+// with lock never waits on a descendant.
+func (c *Confection) rlock() (unlock func()) {
+	l := c.lineage()
+	for _, r := range l {
+		r.mu.RLock()
+	}
+	return func() {
+		for _, r := range l {
+			r.mu.RUnlock()
+		}
+	}
+}
+
+// lock write-locks c and read-locks its ancestors, and returns a func that
+// unlocks them.
+func (c *Confection) lock() (unlock func()) {
+	c.mu.Lock()
+	unlockParent := func() {}
+	if c.parent != nil {
+		unlockParent = c.parent.rlock()
+	}
+	return func() {
+		unlockParent()
+		c.mu.Unlock()
+	}
+}
+
+// localInterface returns c's own entry for the named Interface, creating it
+// if the Interface is registered only with an ancestor. The caller must hold
+// c.lock and have checked that the Interface is registered.
+func (c *Confection) localInterface(name string) *_interface {
+	iface, ok := c.interfaces[name]
+	if !ok {
+		t, _ := c.interfaceType(name)
+		iface = &_interface{_type: t}
+		c.interfaces[name] = iface
+	}
+	return iface
+}
+
+// The following accessors present the merged view of c and its ancestors.
+// The caller must hold c.rlock or c.lock.
+
+// interfaceType returns the type of the named Interface.
+func (c *Confection) interfaceType(name string) (reflect.Type, bool) {
+	for _, r := range c.lineage() {
+		if iface, ok := r.interfaces[name]; ok {
+			return iface._type, true
+		}
+	}
+	return nil, false
+}
+
+// interfaceTypes returns every Interface visible from c, by name.
+func (c *Confection) interfaceTypes() map[string]reflect.Type {
+	types := make(map[string]reflect.Type)
+	for _, r := range c.lineage() {
+		for name, iface := range r.interfaces {
+			if _, ok := types[name]; !ok {
+				types[name] = iface._type
+			}
+		}
+	}
+	return types
+}
+
+// factory returns the factory for typeName under the named Interface.
+func (c *Confection) factory(interfaceName, typeName string) *registration {
+	for _, r := range c.lineage() {
+		if iface, ok := r.interfaces[interfaceName]; ok {
+			if reg, ok := iface.registeredTypes[typeName]; ok {
+				return reg
+			}
+		}
+	}
+	return nil
+}
+
+// factories returns every factory for the named Interface, by @type name.
+func (c *Confection) factories(interfaceName string) map[string]*registration {
+	regs := make(map[string]*registration)
+	for _, r := range c.lineage() {
+		if iface, ok := r.interfaces[interfaceName]; ok {
+			for typeName, reg := range iface.registeredTypes {
+				if _, ok := regs[typeName]; !ok {
+					regs[typeName] = reg
+				}
+			}
+		}
+	}
+	return regs
+}
+
+// alias returns the name the alias typeName stands for.
+func (c *Confection) alias(typeName string) (string, bool) {
+	for _, r := range c.lineage() {
+		if target, ok := r.aliases[typeName]; ok {
+			return target, true
+		}
+	}
+	return "", false
+}
+
+// aliasTarget follows aliases from typeName to a name that is not an alias.
+// Registration keeps aliases from looping within a registry, but a parent may
+// later add an alias that loops with a child's, so the walk stops at a name it
+// has already seen.
+func (c *Confection) aliasTarget(typeName string) string {
+	seen := map[string]bool{typeName: true}
+	for {
+		target, ok := c.alias(typeName)
+		if !ok || seen[target] {
+			return typeName
+		}
+		seen[target] = true
+		typeName = target
+	}
+}
+
+// conversion returns the conversion from typeName.
+func (c *Confection) conversion(typeName string) *conversion {
+	for _, r := range c.lineage() {
+		if conv, ok := r.conversions[typeName]; ok {
+			return conv
+		}
+	}
+	return nil
+}
+
+// deprecationOf returns the deprecation of typeName.
+func (c *Confection) deprecationOf(typeName string) (Deprecation, bool) {
+	for _, r := range c.lineage() {
+		if d, ok := r.deprecations[typeName]; ok {
+			return d, true
+		}
+	}
+	return Deprecation{}, false
+}
+
+// names returns every @type name visible from c that pick selects from a
+// registry, without duplicates.
+func (c *Confection) names(pick func(*Confection) []string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, r := range c.lineage() {
+		for _, name := range pick(r) {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
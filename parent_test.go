@@ -0,0 +1,155 @@
+package confection_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/raphaelreyna/confection"
+	"gopkg.in/yaml.v3"
+)
+
+func FakeEnglishFactory(context.Context, *EnglishConfig) (*English, error) {
+	return &English{phrase: "fake"}, nil
+}
+
+func greet(t *testing.T, c *confection.Confection, typeName string) string {
+	t.Helper()
+	tc, err := confection.NewTypedConfig("", typeName, nil)
+	if err != nil {
+		t.Fatalf("NewTypedConfig: %s", err)
+	}
+	g, err := confection.Make[Greeter](c, tc)
+	if err != nil {
+		t.Fatalf("Make %s: %s", typeName, err)
+	}
+	return g.Greet()
+}
+
+func TestParent_FallbackAndShadowing(t *testing.T) {
+	parent := confection.NewConfection()
+	confection.RegisterInterface[Greeter](parent)
+	confection.RegisterFactory(parent, "greetings.english", EnglishFactory)
+	confection.RegisterFactory(parent, "greetings.spanish", SpanishFactory)
+
+	child := confection.NewConfection(confection.WithParent(parent))
+	confection.RegisterFactory(child, "greetings.english", FakeEnglishFactory)
+
+	if got := greet(t, child, "greetings.english"); got != "fake" {
+		t.Errorf("expected the child's factory to shadow the parent's, got %q", got)
+	}
+	if got := greet(t, child, "greetings.spanish"); got != "Hola, ¿cómo estás?" {
+		t.Errorf("expected the parent's factory, got %q", got)
+	}
+	if got := greet(t, parent, "greetings.english"); got != "Hello" {
+		t.Errorf("expected the parent to be unchanged, got %q", got)
+	}
+
+	// registrations made with the parent later are visible too
+	confection.RegisterAlias(parent, "greetings.en", "greetings.english")
+	if got := greet(t, child, "greetings.en"); got != "fake" {
+		t.Errorf("expected the parent's alias to resolve to the child's factory, got %q", got)
+	}
+}
+
+func TestParent_RegisterErrors(t *testing.T) {
+	parent := confection.NewConfection()
+	confection.RegisterInterface[Greeter](parent)
+	confection.RegisterFactory(parent, "greetings.english", EnglishFactory)
+	child := confection.NewConfection(confection.WithParent(parent))
+
+	if err := confection.TryRegisterInterface[Greeter](child); !errors.Is(err, confection.ErrDuplicateInterface) {
+		t.Errorf("expected ErrDuplicateInterface for an Interface registered with the parent, got %v", err)
+	}
+	if err := confection.TryRegisterFactory(child, "greetings.english", FakeEnglishFactory); err != nil {
+		t.Fatalf("expected shadowing to succeed, got %v", err)
+	}
+	if err := confection.TryRegisterFactory(child, "greetings.english", FakeEnglishFactory); !errors.Is(err, confection.ErrDuplicateType) {
+		t.Errorf("expected ErrDuplicateType registering twice with the child, got %v", err)
+	}
+	if err := confection.TryRegisterFactory(child, "wrapper", func(context.Context, struct{}) (*WrapperImpl, error) {
+		return &WrapperImpl{}, nil
+	}); !errors.Is(err, confection.ErrInterfaceNotRegistered) {
+		t.Errorf("expected ErrInterfaceNotRegistered, got %v", err)
+	}
+
+	confection.RegisterFactory(parent, "greetings.spanish", SpanishFactory)
+	if err := confection.TryRegisterAlias(child, "greetings.spanish", "greetings.english"); !errors.Is(err, confection.ErrDuplicateType) {
+		t.Errorf("expected ErrDuplicateType for an alias shadowing a parent's factory, got %v", err)
+	}
+}
+
+func TestParent_Introspection(t *testing.T) {
+	parent := confection.NewConfection()
+	confection.RegisterInterface[Greeter](parent)
+	confection.RegisterFactory(parent, "greetings.english", EnglishFactory)
+
+	child := confection.NewConfection(confection.WithParent(parent))
+	confection.RegisterInterface[Wrapper](child)
+	confection.RegisterFactory(child, "greetings.spanish", SpanishFactory)
+
+	infos := confection.Interfaces(child)
+	if len(infos) != 2 || infos[0].Name != "confection_test.Greeter" || infos[1].Name != "confection_test.Wrapper" {
+		t.Fatalf("expected Greeter and Wrapper, got %+v", infos)
+	}
+	if len(infos[0].Factories) != 2 {
+		t.Errorf("expected both registries' factories, got %+v", infos[0].Factories)
+	}
+	if _, ok := confection.LookupFactory[Greeter](child, "greetings.english"); !ok {
+		t.Error("expected LookupFactory to find the parent's factory")
+	}
+	if got := confection.Factories[Greeter](parent); len(got) != 1 {
+		t.Errorf("expected the parent to have only its own factory, got %+v", got)
+	}
+	if got := confection.Interfaces(parent); len(got) != 1 {
+		t.Errorf("expected the parent to have only its own Interface, got %+v", got)
+	}
+}
+
+func TestParent_InheritsOptions(t *testing.T) {
+	var w warnings
+	parent := confection.NewConfection(
+		confection.WithShape(confection.FlatShape),
+		confection.WithTypeKey("kind"),
+		confection.WithStrictDecoding(),
+		confection.WithWarningHandler(w.handle),
+	)
+	confection.RegisterInterface[Greeter](parent)
+	confection.RegisterFactory(parent, "greetings.english", EnglishFactory)
+	child := confection.NewConfection(confection.WithTypeKey("type"), confection.WithParent(parent))
+	useGlobal(t, child)
+
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte("type: greetings.english\ngreeting: Howdy\nextra: true\n"), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	if tc.Type() != "greetings.english" {
+		t.Fatalf("expected the child's type key and the parent's shape, got %s", tc.String())
+	}
+	if _, err := confection.Make[Greeter](child, tc); !errors.Is(err, confection.ErrUnknownField) {
+		t.Errorf("expected strict decoding inherited from the parent, got %v", err)
+	}
+
+	confection.Deprecate(child, "greetings.english", confection.Deprecation{})
+	if err := yaml.Unmarshal([]byte("type: greetings.english\n"), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	if err := confection.Validate[Greeter](child, tc); err != nil {
+		t.Fatalf("Validate: %s", err)
+	}
+	if len(w.list) != 1 {
+		t.Errorf("expected the parent's warning handler to be inherited, got %d warnings", len(w.list))
+	}
+}
+
+func TestParent_Global(t *testing.T) {
+	global := confection.NewConfection()
+	confection.RegisterInterface[Greeter](global)
+	confection.RegisterFactory(global, "greetings.english", EnglishFactory)
+	useGlobal(t, global)
+
+	child := confection.NewConfection(confection.WithParent(nil))
+	if got := greet(t, child, "greetings.english"); got != "Hello" {
+		t.Errorf("expected the global factory, got %q", got)
+	}
+}
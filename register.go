@@ -11,20 +11,34 @@ import (
 
 // RegisterInterface registers an interface type with the given Confection registry.
 // Pass nil to use the global registry.
-// Panics if the interface is already registered.
+// Panics if the interface is already registered or c is sealed.
 func RegisterInterface[I Interface](c *Confection) {
+	if err := TryRegisterInterface[I](c); err != nil {
+		panic(err)
+	}
+}
+
+// TryRegisterInterface is like RegisterInterface but returns an error
+// wrapping ErrDuplicateInterface or ErrSealed instead of panicking.
+func TryRegisterInterface[I Interface](c *Confection) error {
 	conf := getConfection(c)
 
-	name := reflect.TypeFor[I]().String()
+	t := reflect.TypeFor[I]()
+	name := t.String()
 
-	conf.mu.Lock()
-	defer conf.mu.Unlock()
+	unlock := conf.lock()
+	defer unlock()
 
-	if _, ok := conf.interfaces[name]; ok {
-		panic(fmt.Sprintf("unable to register Interface %q: Interface already registered", name))
+	if conf.sealed {
+		return fmt.Errorf("unable to register Interface %q: %w", name, ErrSealed)
+	}
+	if _, ok := conf.interfaceType(name); ok {
+		return fmt.Errorf("unable to register Interface %q: %w", name, ErrDuplicateInterface)
 	}
 
-	conf.interfaces[name] = &_interface{}
+	conf.interfaces[name] = &_interface{_type: t}
+
+	return nil
 }
 
 // Factory is a function that creates an Implementation from a Configuration.
@@ -35,17 +49,135 @@ type Factory[Configuration any, Implementation any] func(context.Context, Config
 // The Implementation must be a pointer to a struct that embeds one or more
 // registered confection Interface types.
 // Pass nil for c to use the global registry.
-// Panics on invalid types, unregistered interfaces, or duplicate registrations.
+// Panics on invalid types, unregistered interfaces, duplicate registrations,
+// or if c is sealed.
 func RegisterFactory[Configuration any, Implementation any](c *Confection, typeName string, factory Factory[Configuration, Implementation]) {
+	if err := TryRegisterFactory(c, typeName, factory); err != nil {
+		panic(err)
+	}
+}
+
+// TryRegisterFactory is like RegisterFactory but returns an error instead of
+// panicking. The error wraps ErrInvalidImplementation, ErrInterfaceNotRegistered,
+// ErrDuplicateType or ErrSealed. On error the registry is left unchanged.
+func TryRegisterFactory[Configuration any, Implementation any](c *Confection, typeName string, factory Factory[Configuration, Implementation]) error {
 	conf := getConfection(c)
 
-	t := reflect.TypeFor[Implementation]()
+	interfaceNames, err := implementedInterfaces(reflect.TypeFor[Implementation](), typeName)
+	if err != nil {
+		return err
+	}
+	reg := newRegistration(factory)
+
+	unlock := conf.lock()
+	defer unlock()
+
+	if err := conf.checkFactory(typeName, interfaceNames, false); err != nil {
+		return err
+	}
+	conf.setFactory(typeName, interfaceNames, reg)
+
+	return nil
+}
+
+// checkFactory reports whether a factory implementing interfaceNames may be
+// registered with c under typeName, replacing one already registered with c
+// only if replace is set. The caller must hold c.lock.
+func (c *Confection) checkFactory(typeName string, interfaceNames []string, replace bool) error {
+	if c.sealed {
+		return fmt.Errorf("unable to register factory with config name %q: %w", typeName, ErrSealed)
+	}
+	if target, ok := c.alias(typeName); ok {
+		return fmt.Errorf("unable to register factory with config name %q: it is an alias of %q: %w", typeName, target, ErrDuplicateType)
+	}
+	// check every Interface before registering so a failure leaves the registry untouched
+	for _, interfaceName := range interfaceNames {
+		if _, ok := c.interfaceType(interfaceName); !ok {
+			return fmt.Errorf("unable to register factory with config name %q for Interface %q: %w", typeName, interfaceName, ErrInterfaceNotRegistered)
+		}
+		// factories registered with an ancestor may be shadowed
+		if iface, ok := c.interfaces[interfaceName]; ok && !replace {
+			if _, exists := iface.registeredTypes[typeName]; exists {
+				return fmt.Errorf("unable to register factory with config name %q for Interface %q: %w", typeName, interfaceName, ErrDuplicateType)
+			}
+		}
+	}
+	return nil
+}
+
+// setFactory registers reg with c under typeName for each of interfaceNames.
+// The caller must hold c.lock.
+func (c *Confection) setFactory(typeName string, interfaceNames []string, reg *registration) {
+	for _, interfaceName := range interfaceNames {
+		iface := c.localInterface(interfaceName)
+		if iface.registeredTypes == nil {
+			iface.registeredTypes = make(map[string]*registration)
+		}
+		iface.registeredTypes[typeName] = reg
+	}
+}
+
+func newRegistration[Configuration any, Implementation any](factory Factory[Configuration, Implementation]) *registration {
+	return &registration{
+		configType: reflect.TypeFor[Configuration](),
+		implType:   reflect.TypeFor[Implementation](),
+		decode: func(node *yaml.Node) (any, error) {
+			var config Configuration
+			if err := node.Decode(&config); err != nil {
+				return nil, err
+			}
+			if err := prepareConfig(&config); err != nil {
+				return nil, err
+			}
+			return config, nil
+		},
+		build: func(ctx context.Context, config any) (any, error) {
+			c, ok := config.(Configuration)
+			if !ok {
+				return nil, fmt.Errorf("config is %T, factory takes %s", config, reflect.TypeFor[Configuration]())
+			}
+			return factory(ctx, c)
+		},
+	}
+}
+
+// prepareConfig runs the Defaulter and Validator hooks on a decoded config.
+// Hooks are looked up on both the config and a pointer to it, so value
+// configurations may implement them with pointer receivers.
+func prepareConfig[Configuration any](config *Configuration) error {
+	if v := reflect.ValueOf(config).Elem(); v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil
+	}
+
+	if d, ok := any(*config).(Defaulter); ok {
+		d.SetDefaults()
+	} else if d, ok := any(config).(Defaulter); ok {
+		d.SetDefaults()
+	}
+
+	v, ok := any(*config).(Validator)
+	if !ok {
+		v, ok = any(config).(Validator)
+	}
+	if ok {
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// implementedInterfaces returns the names of the Interfaces that implType
+// implements, as declared by its embedded Interface fields and their tags.
+func implementedInterfaces(implType reflect.Type, typeName string) ([]string, error) {
+	t := implType
 	if t.Kind() != reflect.Ptr {
-		panic(fmt.Sprintf("unable to register factory: type %s is not a pointer to a struct", t))
+		return nil, fmt.Errorf("unable to register factory: type %s is not a pointer to a struct: %w", t, ErrInvalidImplementation)
 	}
 	t = t.Elem()
 	if t.Kind() != reflect.Struct {
-		panic(fmt.Sprintf("unable to register factory: type %s is not a struct", t))
+		return nil, fmt.Errorf("unable to register factory: type %s is not a struct: %w", t, ErrInvalidImplementation)
 	}
 
 	// find all Interfaces that the output type implements
@@ -72,7 +204,7 @@ func RegisterFactory[Configuration any, Implementation any](c *Confection, typeN
 			tag = "implement"
 		}
 		if tag != "implement" && tag != "-" && tag != "" {
-			panic(fmt.Sprintf("unable to register factory with config name %q for Interface %q: invalid tag %q", typeName, ifaceType.String(), tag))
+			return nil, fmt.Errorf("unable to register factory with config name %q for Interface %q: invalid tag %q: %w", typeName, ifaceType.String(), tag, ErrInvalidImplementation)
 		}
 		interfaceNamesAndTags[ifaceType.String()] = tag
 	}
@@ -92,30 +224,5 @@ func RegisterFactory[Configuration any, Implementation any](c *Confection, typeN
 		}
 	}
 
-	conf.mu.Lock()
-	defer conf.mu.Unlock()
-
-	// register the factory for each Interface under the config type name
-	for _, interfaceName := range interfaceNames {
-		iface, ok := conf.interfaces[interfaceName]
-		if !ok {
-			panic(fmt.Sprintf("unable to register factory with config name %q for Interface %q: Interface not found", typeName, interfaceName))
-		}
-		if iface.registeredTypes == nil {
-			iface.registeredTypes = make(map[string]func(context.Context, *yaml.Node) (any, error))
-		}
-
-		_, exists := iface.registeredTypes[typeName]
-		if exists {
-			panic(fmt.Sprintf("unable to register factory with config name %q for Interface %q: configuration type double registration", typeName, interfaceName))
-		}
-
-		iface.registeredTypes[typeName] = func(ctx context.Context, node *yaml.Node) (any, error) {
-			var config Configuration
-			if err := node.Decode(&config); err != nil {
-				return nil, err
-			}
-			return factory(ctx, config)
-		}
-	}
+	return interfaceNames, nil
 }
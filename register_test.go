@@ -0,0 +1,90 @@
+package confection_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/raphaelreyna/confection"
+)
+
+type NotAPointer struct {
+	Greeter
+}
+
+type BadTag struct {
+	Greeter `confection:"bogus"`
+}
+
+type GreeterAndWrapper struct {
+	Greeter
+	Wrapper
+}
+
+func TestTryRegisterInterface_Duplicate(t *testing.T) {
+	c := confection.NewConfection()
+	if err := confection.TryRegisterInterface[Greeter](c); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err := confection.TryRegisterInterface[Greeter](c)
+	if !errors.Is(err, confection.ErrDuplicateInterface) {
+		t.Fatalf("expected ErrDuplicateInterface, got %v", err)
+	}
+}
+
+func TestTryRegisterFactory_Errors(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+
+	if err := confection.TryRegisterFactory(c, "greetings.english", EnglishFactory); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	err := confection.TryRegisterFactory(c, "greetings.english", EnglishFactory)
+	if !errors.Is(err, confection.ErrDuplicateType) {
+		t.Errorf("expected ErrDuplicateType, got %v", err)
+	}
+
+	err = confection.TryRegisterFactory(c, "not.a.pointer", func(context.Context, struct{}) (NotAPointer, error) {
+		return NotAPointer{}, nil
+	})
+	if !errors.Is(err, confection.ErrInvalidImplementation) {
+		t.Errorf("expected ErrInvalidImplementation for non-pointer, got %v", err)
+	}
+
+	err = confection.TryRegisterFactory(c, "bad.tag", func(context.Context, struct{}) (*BadTag, error) {
+		return &BadTag{}, nil
+	})
+	if !errors.Is(err, confection.ErrInvalidImplementation) {
+		t.Errorf("expected ErrInvalidImplementation for bad tag, got %v", err)
+	}
+}
+
+func TestTryRegisterFactory_InterfaceNotRegisteredLeavesRegistryUntouched(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+
+	err := confection.TryRegisterFactory(c, "both", func(context.Context, struct{}) (*GreeterAndWrapper, error) {
+		return &GreeterAndWrapper{}, nil
+	})
+	if !errors.Is(err, confection.ErrInterfaceNotRegistered) {
+		t.Fatalf("expected ErrInterfaceNotRegistered, got %v", err)
+	}
+	if _, ok := confection.LookupFactory[Greeter](c, "both"); ok {
+		t.Error("expected failed registration to leave Greeter untouched")
+	}
+}
+
+func TestRegisterFactory_PanicsWithError(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+
+	defer func() {
+		err, ok := recover().(error)
+		if !ok || !errors.Is(err, confection.ErrDuplicateType) {
+			t.Errorf("expected panic with ErrDuplicateType, got %v", err)
+		}
+	}()
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+}
@@ -0,0 +1,131 @@
+package confection
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Unregister removes the factory registered with c under typeName from every
+// Interface. Factories registered with an ancestor of c are not affected, and
+// become visible again if c's factory shadowed them. Configs already being
+// constructed with the factory are unaffected.
+// Pass nil for c to use the global registry.
+// Panics if c has no factory for typeName or c is sealed.
+func Unregister(c *Confection, typeName string) {
+	if err := TryUnregister(c, typeName); err != nil {
+		panic(err)
+	}
+}
+
+// TryUnregister is like Unregister but returns an error wrapping
+// ErrUnknownType or ErrSealed instead of panicking.
+func TryUnregister(c *Confection, typeName string) error {
+	conf := getConfection(c)
+
+	unlock := conf.lock()
+	defer unlock()
+
+	if conf.sealed {
+		return fmt.Errorf("unable to unregister %q: %w", typeName, ErrSealed)
+	}
+	if len(conf.removeFactory(typeName)) == 0 {
+		return fmt.Errorf("unable to unregister %q: %w", typeName, ErrUnknownType)
+	}
+
+	return nil
+}
+
+// ReplaceFactory registers factory under typeName like RegisterFactory, but
+// replaces the factory already registered with c under that name, if any,
+// instead of failing. The old factory is removed from every Interface, so
+// the name is bound only to the Interfaces the new Implementation implements.
+// Pass nil for c to use the global registry.
+// Panics if the factory cannot be registered.
+func ReplaceFactory[Configuration any, Implementation any](c *Confection, typeName string, factory Factory[Configuration, Implementation]) {
+	if err := TryReplaceFactory(c, typeName, factory); err != nil {
+		panic(err)
+	}
+}
+
+// TryReplaceFactory is like ReplaceFactory but returns an error wrapping
+// ErrInvalidImplementation, ErrInterfaceNotRegistered, ErrDuplicateType if
+// typeName is an alias, or ErrSealed instead of panicking. On error the
+// registry is left unchanged.
+func TryReplaceFactory[Configuration any, Implementation any](c *Confection, typeName string, factory Factory[Configuration, Implementation]) error {
+	_, err := replaceFactory(c, typeName, factory)
+	return err
+}
+
+// OverrideFactory replaces the factory registered under typeName like
+// ReplaceFactory, and returns a func that restores the factories c had for
+// typeName before the override, for use with t.Cleanup or defer:
+//
+//	t.Cleanup(confection.OverrideFactory(nil, "greetings.english", FakeEnglishFactory))
+//
+// restore undoes any change made to typeName's factories since the override,
+// and works even if c has been sealed in the meantime.
+// Pass nil for c to use the global registry.
+// Panics if the factory cannot be registered.
+func OverrideFactory[Configuration any, Implementation any](c *Confection, typeName string, factory Factory[Configuration, Implementation]) (restore func()) {
+	restore, err := TryOverrideFactory(c, typeName, factory)
+	if err != nil {
+		panic(err)
+	}
+	return restore
+}
+
+// TryOverrideFactory is like OverrideFactory but returns the errors of
+// TryReplaceFactory instead of panicking.
+func TryOverrideFactory[Configuration any, Implementation any](c *Confection, typeName string, factory Factory[Configuration, Implementation]) (restore func(), err error) {
+	previous, err := replaceFactory(c, typeName, factory)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := getConfection(c)
+	return func() {
+		unlock := conf.lock()
+		defer unlock()
+
+		conf.removeFactory(typeName)
+		for interfaceName, reg := range previous {
+			conf.setFactory(typeName, []string{interfaceName}, reg)
+		}
+	}, nil
+}
+
+// replaceFactory replaces c's factories for typeName with factory and returns
+// the ones it replaced, by Interface name.
+func replaceFactory[Configuration any, Implementation any](c *Confection, typeName string, factory Factory[Configuration, Implementation]) (map[string]*registration, error) {
+	conf := getConfection(c)
+
+	interfaceNames, err := implementedInterfaces(reflect.TypeFor[Implementation](), typeName)
+	if err != nil {
+		return nil, err
+	}
+	reg := newRegistration(factory)
+
+	unlock := conf.lock()
+	defer unlock()
+
+	if err := conf.checkFactory(typeName, interfaceNames, true); err != nil {
+		return nil, err
+	}
+	previous := conf.removeFactory(typeName)
+	conf.setFactory(typeName, interfaceNames, reg)
+
+	return previous, nil
+}
+
+// removeFactory removes c's own factories for typeName and returns them, by
+// Interface name. The caller must hold c.mu for writing.
+func (c *Confection) removeFactory(typeName string) map[string]*registration {
+	removed := make(map[string]*registration)
+	for interfaceName, iface := range c.interfaces {
+		if reg, ok := iface.registeredTypes[typeName]; ok {
+			removed[interfaceName] = reg
+			delete(iface.registeredTypes, typeName)
+		}
+	}
+	return removed
+}
@@ -0,0 +1,111 @@
+package confection_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/raphaelreyna/confection"
+)
+
+type WrapperOnly struct {
+	Wrapper
+}
+
+func TestUnregister(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+
+	confection.Unregister(c, "greetings.english")
+	if _, ok := confection.LookupFactory[Greeter](c, "greetings.english"); ok {
+		t.Error("expected the factory to be removed")
+	}
+	if err := confection.TryUnregister(c, "greetings.english"); !errors.Is(err, confection.ErrUnknownType) {
+		t.Errorf("expected ErrUnknownType unregistering twice, got %v", err)
+	}
+	// the name is free again
+	confection.RegisterFactory(c, "greetings.english", FakeEnglishFactory)
+	if got := greet(t, c, "greetings.english"); got != "fake" {
+		t.Errorf("expected the new factory, got %q", got)
+	}
+}
+
+func TestUnregister_UncoversParent(t *testing.T) {
+	parent := confection.NewConfection()
+	confection.RegisterInterface[Greeter](parent)
+	confection.RegisterFactory(parent, "greetings.english", EnglishFactory)
+	child := confection.NewConfection(confection.WithParent(parent))
+	confection.RegisterFactory(child, "greetings.english", FakeEnglishFactory)
+
+	confection.Unregister(child, "greetings.english")
+	if got := greet(t, child, "greetings.english"); got != "Hello" {
+		t.Errorf("expected the parent's factory, got %q", got)
+	}
+}
+
+func TestReplaceFactory(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterInterface[Wrapper](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+
+	confection.ReplaceFactory(c, "greetings.english", FakeEnglishFactory)
+	if got := greet(t, c, "greetings.english"); got != "fake" {
+		t.Errorf("expected the replacement, got %q", got)
+	}
+
+	// replacing with an Implementation of other Interfaces rebinds the name
+	if err := confection.TryReplaceFactory(c, "greetings.english", func(context.Context, struct{}) (*WrapperOnly, error) {
+		return &WrapperOnly{}, nil
+	}); err != nil {
+		t.Fatalf("TryReplaceFactory: %s", err)
+	}
+	if _, ok := confection.LookupFactory[Greeter](c, "greetings.english"); ok {
+		t.Error("expected the name to be unbound from Greeter")
+	}
+	if _, ok := confection.LookupFactory[Wrapper](c, "greetings.english"); !ok {
+		t.Error("expected the name to be bound to Wrapper")
+	}
+
+	c.Seal()
+	if err := confection.TryReplaceFactory(c, "greetings.english", EnglishFactory); !errors.Is(err, confection.ErrSealed) {
+		t.Errorf("expected ErrSealed, got %v", err)
+	}
+	if _, err := confection.TryOverrideFactory(c, "greetings.english", EnglishFactory); !errors.Is(err, confection.ErrSealed) {
+		t.Errorf("expected ErrSealed, got %v", err)
+	}
+	if err := confection.TryUnregister(c, "greetings.english"); !errors.Is(err, confection.ErrSealed) {
+		t.Errorf("expected ErrSealed, got %v", err)
+	}
+	func() {
+		defer func() {
+			if err, _ := recover().(error); !errors.Is(err, confection.ErrSealed) {
+				t.Errorf("expected ReplaceFactory to panic with ErrSealed, got %v", err)
+			}
+		}()
+		confection.ReplaceFactory(c, "greetings.english", EnglishFactory)
+	}()
+}
+
+func TestOverrideFactory(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+
+	t.Run("override", func(t *testing.T) {
+		t.Cleanup(confection.OverrideFactory(c, "greetings.english", FakeEnglishFactory))
+		t.Cleanup(confection.OverrideFactory(c, "greetings.spanish", FakeEnglishFactory))
+		if got := greet(t, c, "greetings.english"); got != "fake" {
+			t.Errorf("expected the override, got %q", got)
+		}
+		c.Seal()
+	})
+
+	if got := greet(t, c, "greetings.english"); got != "Hello" {
+		t.Errorf("expected the original factory after cleanup, got %q", got)
+	}
+	if _, ok := confection.LookupFactory[Greeter](c, "greetings.spanish"); ok {
+		t.Error("expected a factory added by an override to be removed by cleanup")
+	}
+}
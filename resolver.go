@@ -0,0 +1,97 @@
+package confection
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Resolver is consulted when a config names a @type that has no factory for
+// the Interface being constructed. It may register a factory for typeName
+// with c, for example by loading a plugin or matching a naming pattern, and
+// returns nil whether or not it did; the lookup is retried after it returns.
+// interfaceName is the name of the Interface being constructed and typeName
+// is the @type after following aliases.
+//
+// Resolvers are called without holding the registry lock, and may be called
+// concurrently for the same type. A resolver that loses such a race to
+// register a factory may return the ErrDuplicateType error from
+// TryRegisterFactory; it is treated as success. Any other error, including
+// ErrSealed from a sealed registry, fails the Make or Validate call.
+type Resolver func(ctx context.Context, c *Confection, interfaceName, typeName string) error
+
+// RegisterResolver adds r to the resolvers consulted for unknown @type names
+// when constructing interface I. Resolvers for I are tried in the order they
+// were added, before those added with RegisterDefaultResolver, until one of
+// them registers a factory. Pass nil for c to use the global registry.
+// Panics if I is not registered or c is sealed.
+func RegisterResolver[I Interface](c *Confection, r Resolver) {
+	if err := TryRegisterResolver[I](c, r); err != nil {
+		panic(err)
+	}
+}
+
+// TryRegisterResolver is like RegisterResolver but returns an error wrapping
+// ErrInterfaceNotRegistered or ErrSealed instead of panicking.
+func TryRegisterResolver[I Interface](c *Confection, r Resolver) error {
+	conf := getConfection(c)
+	name := reflect.TypeFor[I]().String()
+
+	unlock := conf.lock()
+	defer unlock()
+
+	if conf.sealed {
+		return fmt.Errorf("unable to register resolver for Interface %q: %w", name, ErrSealed)
+	}
+	if _, ok := conf.interfaceType(name); !ok {
+		return fmt.Errorf("unable to register resolver for Interface %q: %w", name, ErrInterfaceNotRegistered)
+	}
+	iface := conf.localInterface(name)
+	iface.resolvers = append(iface.resolvers, r)
+
+	return nil
+}
+
+// RegisterDefaultResolver adds r to the resolvers consulted for unknown @type
+// names when constructing any Interface, after the Interface's own resolvers.
+// Pass nil for c to use the global registry.
+// Panics with ErrSealed if c is sealed.
+func RegisterDefaultResolver(c *Confection, r Resolver) {
+	if err := TryRegisterDefaultResolver(c, r); err != nil {
+		panic(err)
+	}
+}
+
+// TryRegisterDefaultResolver is like RegisterDefaultResolver but returns an
+// error wrapping ErrSealed instead of panicking.
+func TryRegisterDefaultResolver(c *Confection, r Resolver) error {
+	conf := getConfection(c)
+
+	unlock := conf.lock()
+	defer unlock()
+
+	if conf.sealed {
+		return fmt.Errorf("unable to register default resolver: %w", ErrSealed)
+	}
+	conf.resolvers = append(conf.resolvers, r)
+
+	return nil
+}
+
+// resolversFor returns the resolvers to consult for the named Interface, in
+// order: the Interface's own, nearest registry first, then the defaults.
+func (c *Confection) resolversFor(interfaceName string) []Resolver {
+	unlock := c.rlock()
+	defer unlock()
+
+	var resolvers []Resolver
+	for _, r := range c.lineage() {
+		if iface, ok := r.interfaces[interfaceName]; ok {
+			resolvers = append(resolvers, iface.resolvers...)
+		}
+	}
+	for _, r := range c.lineage() {
+		resolvers = append(resolvers, r.resolvers...)
+	}
+	return resolvers
+}
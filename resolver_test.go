@@ -0,0 +1,141 @@
+package confection_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/raphaelreyna/confection"
+)
+
+func TestResolver_RegistersLazily(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+
+	var calls atomic.Int32
+	confection.RegisterResolver[Greeter](c, func(_ context.Context, c *confection.Confection, interfaceName, typeName string) error {
+		calls.Add(1)
+		if interfaceName != "confection_test.Greeter" {
+			t.Errorf("unexpected interface name %q", interfaceName)
+		}
+		if !strings.HasPrefix(typeName, "greetings.") {
+			return nil
+		}
+		return confection.TryRegisterFactory(c, typeName, EnglishFactory)
+	})
+
+	tc, err := confection.NewTypedConfig("", "greetings.english", map[string]string{"greeting": "Howdy"})
+	if err != nil {
+		t.Fatalf("NewTypedConfig: %s", err)
+	}
+	for range 2 {
+		g, err := confection.Make[Greeter](c, tc)
+		if err != nil {
+			t.Fatalf("Make: %s", err)
+		}
+		if got := g.Greet(); got != "Howdy" {
+			t.Errorf("expected %q, got %q", "Howdy", got)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected the resolver to be called once, got %d", n)
+	}
+
+	tc, err = confection.NewTypedConfig("", "farewells.english", nil)
+	if err != nil {
+		t.Fatalf("NewTypedConfig: %s", err)
+	}
+	if _, err := confection.Make[Greeter](c, tc); !errors.Is(err, confection.ErrUnknownType) {
+		t.Errorf("expected ErrUnknownType for a type no resolver handles, got %v", err)
+	}
+}
+
+func TestResolver_Order(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+
+	var order []string
+	record := func(name string, register bool) confection.Resolver {
+		return func(_ context.Context, c *confection.Confection, _, typeName string) error {
+			order = append(order, name)
+			if register {
+				return confection.TryRegisterFactory(c, typeName, EnglishFactory)
+			}
+			return nil
+		}
+	}
+	confection.RegisterDefaultResolver(c, record("default", true))
+	confection.RegisterResolver[Greeter](c, record("first", false))
+	confection.RegisterResolver[Greeter](c, record("second", true))
+	confection.RegisterResolver[Greeter](c, record("third", true))
+
+	tc, err := confection.NewTypedConfig("", "greetings.english", nil)
+	if err != nil {
+		t.Fatalf("NewTypedConfig: %s", err)
+	}
+	if err := confection.Validate[Greeter](c, tc); err != nil {
+		t.Fatalf("Validate: %s", err)
+	}
+	if got := strings.Join(order, ","); got != "first,second" {
+		t.Errorf("expected resolvers to stop after the first to register, got %s", got)
+	}
+}
+
+func TestResolver_Error(t *testing.T) {
+	errPluginMissing := errors.New("plugin not found")
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterDefaultResolver(c, func(context.Context, *confection.Confection, string, string) error {
+		return errPluginMissing
+	})
+
+	tc, err := confection.NewTypedConfig("", "greetings.english", nil)
+	if err != nil {
+		t.Fatalf("NewTypedConfig: %s", err)
+	}
+	_, err = confection.Make[Greeter](c, tc)
+	if !errors.Is(err, errPluginMissing) {
+		t.Fatalf("expected resolver error, got %v", err)
+	}
+	var e *confection.Error
+	if !errors.As(err, &e) || e.Type != "greetings.english" {
+		t.Errorf("expected *Error for the @type, got %v", err)
+	}
+}
+
+func TestResolver_ConcurrentRegistration(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterResolver[Greeter](c, func(_ context.Context, c *confection.Confection, _, typeName string) error {
+		return confection.TryRegisterFactory(c, typeName, EnglishFactory)
+	})
+
+	tc, err := confection.NewTypedConfig("", "greetings.english", nil)
+	if err != nil {
+		t.Fatalf("NewTypedConfig: %s", err)
+	}
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := confection.Make[Greeter](c, tc); err != nil {
+				t.Errorf("Make: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTryRegisterResolver_InterfaceNotRegistered(t *testing.T) {
+	c := confection.NewConfection()
+	err := confection.TryRegisterResolver[Greeter](c, func(context.Context, *confection.Confection, string, string) error {
+		return nil
+	})
+	if !errors.Is(err, confection.ErrInterfaceNotRegistered) {
+		t.Errorf("expected ErrInterfaceNotRegistered, got %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package confection
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaDialect is the JSON Schema dialect emitted by JSONSchema.
+const SchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema document. It covers the subset of the
+// specification needed to describe TypedConfig blocks and is meant
+// to be serialized with encoding/json.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Deprecated           bool               `json:"deprecated,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Const                any                `json:"const,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+// JSONSchema returns a JSON Schema describing a TypedConfig for interface I.
+// The config block is a oneOf over every registered @type, with the
+// discriminator pinned as a const and the remaining properties derived from
+// the factory's Configuration type, honouring yaml struct tags. The schema
+// follows the registry's discriminator key and shape. Older versions with
+// conversions and aliases get branches of their own, and deprecated names
+// are marked deprecated.
+// Pass nil for c to use the global registry.
+func JSONSchema[I Interface](c *Confection) (*Schema, error) {
+	conf := getConfection(c)
+	name := reflect.TypeFor[I]().String()
+
+	factories := Factories[I](conf)
+	if factories == nil {
+		return nil, fmt.Errorf("interface %s: %w", name, ErrInterfaceNotRegistered)
+	}
+
+	sb := schemaBuilder{format: conf.format, visiting: map[reflect.Type]bool{}}
+	branches := make([]*Schema, 0, len(factories))
+	// older versions that convert to a registered type, and aliases, are valid too
+	factories = append(factories, conf.convertibleTypes(name)...)
+	factories = append(factories, conf.aliasedTypes(name)...)
+	for _, f := range factories {
+		branch := sb.schemaFor(f.Config)
+		if branch.Type != "object" || branch.Properties == nil {
+			branch = &Schema{Type: "object", Properties: map[string]*Schema{}}
+		}
+		branch.Title = f.Type
+		if d := conf.deprecated(f.Type); d != nil {
+			branch.Deprecated = true
+			branch.Description = d.Message
+		}
+		keys := conf.format.keys()
+		for i, value := range conf.format.values(f.Type) {
+			branch.Properties[keys[i]] = &Schema{Const: value}
+		}
+		branch.Required = append(keys, branch.Required...)
+		switch conf.format.shape {
+		case FlatShape:
+			if branch.Properties["name"] == nil {
+				branch.Properties["name"] = &Schema{Type: "string"}
+			}
+		case KubernetesShape:
+			if branch.Properties["metadata"] == nil {
+				branch.Properties["metadata"] = metadataSchema()
+			}
+		}
+		branches = append(branches, branch)
+	}
+
+	if conf.format.shape != EnvelopeShape {
+		return &Schema{
+			Schema: SchemaDialect,
+			Title:  name,
+			OneOf:  branches,
+		}, nil
+	}
+	return &Schema{
+		Schema: SchemaDialect,
+		Title:  name,
+		Type:   "object",
+		Properties: map[string]*Schema{
+			"name":         {Type: "string"},
+			"typed_config": {OneOf: branches},
+		},
+		Required: []string{"typed_config"},
+	}, nil
+}
+
+var (
+	_typedConfigType = reflect.TypeFor[TypedConfig]()
+	_durationType    = reflect.TypeFor[time.Duration]()
+	_timeType        = reflect.TypeFor[time.Time]()
+	_yamlUnmarshaler = reflect.TypeFor[yaml.Unmarshaler]()
+	_yamlNodeType    = reflect.TypeFor[yaml.Node]()
+)
+
+type schemaBuilder struct {
+	format   format
+	visiting map[reflect.Type]bool
+}
+
+// typedConfigSchema describes a TypedConfig nested inside a factory config,
+// whose Interface is not known statically.
+func (sb *schemaBuilder) typedConfigSchema() *Schema {
+	block := &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{},
+		Required:   sb.format.keys(),
+	}
+	for _, key := range block.Required {
+		block.Properties[key] = &Schema{Type: "string"}
+	}
+	switch sb.format.shape {
+	case FlatShape:
+		return block
+	case KubernetesShape:
+		block.Properties["metadata"] = metadataSchema()
+		return block
+	}
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"name":         {Type: "string"},
+			"typed_config": block,
+		},
+		Required: []string{"typed_config"},
+	}
+}
+
+// metadataSchema describes the metadata block of a KubernetesShape document.
+func metadataSchema() *Schema {
+	return &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"name": {Type: "string"}},
+	}
+}
+
+func (sb *schemaBuilder) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case _typedConfigType:
+		return sb.typedConfigSchema()
+	case _durationType, _timeType:
+		return &Schema{Type: "string"}
+	case _yamlNodeType:
+		return &Schema{}
+	}
+	if t.Implements(_yamlUnmarshaler) || reflect.PointerTo(t).Implements(_yamlUnmarshaler) {
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: sb.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: sb.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if sb.visiting[t] {
+			return &Schema{Type: "object"}
+		}
+		sb.visiting[t] = true
+		defer delete(sb.visiting, t)
+
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		fields, _ := yamlFields(t)
+		for _, field := range fields {
+			s.Properties[field.Name] = sb.schemaFor(field.Type)
+		}
+		return s
+	}
+
+	return &Schema{}
+}
@@ -0,0 +1,104 @@
+package confection_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/raphaelreyna/confection"
+)
+
+func TestJSONSchema(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterInterface[Wrapper](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+	confection.RegisterFactory(c, "greetings.spanish", SpanishFactory)
+
+	schema, err := confection.JSONSchema[Greeter](c)
+	if err != nil {
+		t.Fatalf("JSONSchema: %s", err)
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("marshal: %s", err)
+	}
+
+	var doc struct {
+		Schema     string `json:"$schema"`
+		Properties struct {
+			TypedConfig struct {
+				OneOf []struct {
+					Title      string                    `json:"title"`
+					Properties map[string]map[string]any `json:"properties"`
+					Required   []string                  `json:"required"`
+				} `json:"oneOf"`
+			} `json:"typed_config"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+
+	if doc.Schema != confection.SchemaDialect {
+		t.Errorf("expected $schema %q, got %q", confection.SchemaDialect, doc.Schema)
+	}
+
+	branches := doc.Properties.TypedConfig.OneOf
+	if len(branches) != 2 {
+		t.Fatalf("expected 2 branches, got %d", len(branches))
+	}
+
+	english := branches[0]
+	if english.Title != "greetings.english" {
+		t.Errorf("expected first branch 'greetings.english', got %q", english.Title)
+	}
+	if english.Properties["@type"]["const"] != "greetings.english" {
+		t.Errorf("expected @type const 'greetings.english', got %v", english.Properties["@type"])
+	}
+	if english.Properties["greeting"]["type"] != "string" {
+		t.Errorf("expected greeting to be a string, got %v", english.Properties["greeting"])
+	}
+	if len(english.Required) != 1 || english.Required[0] != "@type" {
+		t.Errorf("expected @type to be required, got %v", english.Required)
+	}
+
+	spanish := branches[1]
+	if spanish.Properties["use_formal"]["type"] != "boolean" {
+		t.Errorf("expected use_formal to be a boolean, got %v", spanish.Properties["use_formal"])
+	}
+	if _, ok := spanish.Properties["Formal"]; ok {
+		t.Error("expected yaml tag name to be used instead of the Go field name")
+	}
+}
+
+func TestJSONSchema_NestedTypedConfig(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterInterface[Wrapper](c)
+	confection.RegisterFactory(c, "wrapper", func(_ context.Context, cfg *WrapperConfig) (*WrapperImpl, error) {
+		return &WrapperImpl{prefix: cfg.Prefix}, nil
+	})
+
+	schema, err := confection.JSONSchema[Wrapper](c)
+	if err != nil {
+		t.Fatalf("JSONSchema: %s", err)
+	}
+
+	branch := schema.Properties["typed_config"].OneOf[0]
+	child := branch.Properties["child"]
+	if child == nil || child.Properties["typed_config"] == nil {
+		t.Fatalf("expected child to be described as a TypedConfig, got %+v", child)
+	}
+	if got := child.Properties["typed_config"].Required; len(got) != 1 || got[0] != "@type" {
+		t.Errorf("expected nested typed_config to require @type, got %v", got)
+	}
+}
+
+func TestJSONSchema_UnregisteredInterface(t *testing.T) {
+	c := confection.NewConfection()
+	if _, err := confection.JSONSchema[Greeter](c); err == nil {
+		t.Fatal("expected error for unregistered interface, got nil")
+	}
+}
@@ -0,0 +1,250 @@
+package confection
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RefKey is the key of a reference. A config block holding it in place of a
+// discriminator stands for the instance built from the TypedConfig of that
+// name in the same Scope:
+//
+//	clusters:
+//	- name: upstream-cluster
+//	  typed_config:
+//	    "@type": pools.cluster
+//	    size: 10
+//	filters:
+//	- typed_config:
+//	    "@ref": upstream-cluster
+//
+// A reference has no other keys and no name of its own.
+const RefKey = "@ref"
+
+// Scope is a table of named instances shared by the MakeCtx calls of one
+// build. MakeCtx with a context carrying a Scope builds each named
+// TypedConfig once, and resolves references to its name to that instance:
+//
+//	scope := confection.NewScope()
+//	if err := scope.Declare(cfg.Clusters...); err != nil { ... }
+//	ctx = confection.WithScope(ctx, scope)
+//	for _, tc := range cfg.Filters {
+//		filter, err := confection.MakeCtx[Filter](ctx, nil, tc)
+//		...
+//	}
+//
+// A reference to a declared config that has not been built yet builds it on
+// the spot, as the referring Interface, so configs are built in dependency
+// order whatever order they are listed in. References that loop fail with a
+// *CycleError listing the loop. A Scope is safe for concurrent use; a named
+// config requested by several goroutines at once is built by one of them.
+type Scope struct {
+	mu       sync.Mutex
+	declared map[string]TypedConfig
+	entries  map[string]*scopeEntry
+}
+
+// scopeEntry is a named instance, built or being built.
+type scopeEntry struct {
+	// source identifies the config the instance is built from
+	source   *yaml.Node
+	done     chan struct{}
+	instance any
+	err      error
+	// waiting is the name of the instance the entry's build is waiting for
+	waiting string
+}
+
+// NewScope returns an empty Scope.
+func NewScope() *Scope {
+	return &Scope{
+		declared: map[string]TypedConfig{},
+		entries:  map[string]*scopeEntry{},
+	}
+}
+
+type scopeKey struct{}
+
+// scopeFrame is the Scope in a context and the names being built along the
+// context's call chain, outermost first.
+type scopeFrame struct {
+	scope    *Scope
+	building []string
+}
+
+// WithScope returns a copy of ctx that makes MakeCtx share named instances
+// through s.
+func WithScope(ctx context.Context, s *Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scopeFrame{scope: s})
+}
+
+// ScopeFrom returns the Scope carried by ctx, if any.
+func ScopeFrom(ctx context.Context) (*Scope, bool) {
+	frame, ok := ctx.Value(scopeKey{}).(scopeFrame)
+	return frame.scope, ok && frame.scope != nil
+}
+
+// Declare makes the named configs in tcs available to references before they
+// are built. Configs without a name are ignored. It fails with
+// ErrDuplicateName if a name is already declared or built from another config.
+func (s *Scope) Declare(tcs ...TypedConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tc := range tcs {
+		if tc.Name == "" {
+			continue
+		}
+		if s.conflicts(tc) {
+			return tc.error("", ErrDuplicateName)
+		}
+		s.declared[tc.Name] = tc
+	}
+	return nil
+}
+
+// Lookup returns the instance built for name, if it was built successfully.
+func (s *Scope) Lookup(name string) (any, bool) {
+	s.mu.Lock()
+	e, ok := s.entries[name]
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, false
+	}
+	select {
+	case <-e.done:
+		return e.instance, e.err == nil
+	default:
+		return nil, false
+	}
+}
+
+// conflicts reports whether tc's name is declared or built from another
+// config. The caller must hold s.mu.
+func (s *Scope) conflicts(tc TypedConfig) bool {
+	if d, ok := s.declared[tc.Name]; ok && d.source() != tc.source() {
+		return true
+	}
+	e, ok := s.entries[tc.Name]
+	return ok && e.source != tc.source()
+}
+
+// get returns the instance for tc, a named config or a reference, calling
+// build to build it if s has none yet. Errors from build are returned as is.
+func (s *Scope) get(ctx context.Context, tc TypedConfig, build func(context.Context, TypedConfig) (any, error)) (any, error) {
+	frame, _ := ctx.Value(scopeKey{}).(scopeFrame)
+	name := tc.Name
+	if tc.ref != "" {
+		name = tc.ref
+	}
+	if i := slices.Index(frame.building, name); i != -1 {
+		return nil, &CycleError{Path: append(slices.Clone(frame.building[i:]), name)}
+	}
+
+	s.mu.Lock()
+	e, built := s.entries[name]
+	switch {
+	case tc.ref == "" && s.conflicts(tc):
+		s.mu.Unlock()
+		return nil, ErrDuplicateName
+	case !built && tc.ref != "":
+		d, ok := s.declared[name]
+		if !ok {
+			s.mu.Unlock()
+			return nil, ErrUnknownReference
+		}
+		tc = d
+	}
+	if built {
+		select {
+		case <-e.done:
+			s.mu.Unlock()
+			return e.instance, e.err
+		default:
+		}
+	} else {
+		e = &scopeEntry{source: tc.source(), done: make(chan struct{})}
+		s.entries[name] = e
+	}
+
+	// the instance being built along ctx, if any, now waits for this one;
+	// another goroutine building this one may be waiting for it in turn
+	var waiter *scopeEntry
+	if n := len(frame.building); n > 0 {
+		if built {
+			if path := s.waitPath(name, frame.building[n-1]); path != nil {
+				s.mu.Unlock()
+				return nil, &CycleError{Path: append([]string{frame.building[n-1]}, path...)}
+			}
+		}
+		waiter = s.entries[frame.building[n-1]]
+		waiter.waiting = name
+	}
+	s.mu.Unlock()
+	defer func() {
+		if waiter != nil {
+			s.mu.Lock()
+			waiter.waiting = ""
+			s.mu.Unlock()
+		}
+	}()
+
+	if !built {
+		frame.scope = s
+		frame.building = append(slices.Clip(frame.building), name)
+		e.instance, e.err = build(context.WithValue(ctx, scopeKey{}, frame), tc)
+		close(e.done)
+		return e.instance, e.err
+	}
+	select {
+	case <-e.done:
+		return e.instance, e.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// waitPath returns the names from name to target along the instances being
+// waited for, or nil if name does not wait for target. The caller must hold
+// s.mu.
+func (s *Scope) waitPath(name, target string) []string {
+	path := []string{name}
+	for name != target {
+		e, ok := s.entries[name]
+		if !ok || e.waiting == "" {
+			return nil
+		}
+		name = e.waiting
+		path = append(path, name)
+	}
+	return path
+}
+
+// source returns the node tc was decoded from, which identifies it.
+func (tc *TypedConfig) source() *yaml.Node {
+	if tc.raw != nil {
+		return tc.raw
+	}
+	return tc.TypedConfig
+}
+
+// CycleError is returned when named TypedConfigs refer to or depend on each
+// other in a loop. It matches ErrCycle.
+type CycleError struct {
+	// Path lists the names along the loop, starting and ending with the same one.
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrCycle, strings.Join(e.Path, " -> "))
+}
+
+func (e *CycleError) Is(target error) bool {
+	return target == ErrCycle
+}
@@ -0,0 +1,311 @@
+package confection_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/raphaelreyna/confection"
+	"gopkg.in/yaml.v3"
+)
+
+// scopeRegistry registers a wrapper factory and an English factory counting
+// its calls in built.
+func scopeRegistry(built *atomic.Int32) *confection.Confection {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterInterface[Wrapper](c)
+	confection.RegisterFactory(c, "greetings.english", func(ctx context.Context, cfg *EnglishConfig) (*English, error) {
+		built.Add(1)
+		return EnglishFactory(ctx, cfg)
+	})
+	confection.RegisterFactory(c, "wrapper", func(ctx context.Context, cfg *WrapperConfig) (*WrapperImpl, error) {
+		inner, err := confection.MakeCtx[Greeter](ctx, c, cfg.Child)
+		if err != nil {
+			return nil, err
+		}
+		return &WrapperImpl{inner: inner, prefix: cfg.Prefix}, nil
+	})
+	return c
+}
+
+func unmarshalConfigs(t *testing.T, input string) []confection.TypedConfig {
+	t.Helper()
+	var tcs []confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(input), &tcs); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	return tcs
+}
+
+func TestScope_SharesNamedInstances(t *testing.T) {
+	var built atomic.Int32
+	c := scopeRegistry(&built)
+
+	tcs := unmarshalConfigs(t, `
+- typed_config:
+    "@type": wrapper
+    prefix: "first: "
+    child:
+      typed_config:
+        "@ref": shared
+- typed_config:
+    "@type": wrapper
+    prefix: "second: "
+    child:
+      typed_config:
+        "@ref": shared
+- name: shared
+  typed_config:
+    "@type": greetings.english
+    greeting: Howdy
+`)
+	scope := confection.NewScope()
+	if err := scope.Declare(tcs...); err != nil {
+		t.Fatalf("Declare: %s", err)
+	}
+	ctx := confection.WithScope(context.Background(), scope)
+
+	var wrappers []Wrapper
+	for _, tc := range tcs[:2] {
+		w, err := confection.MakeCtx[Wrapper](ctx, c, tc)
+		if err != nil {
+			t.Fatalf("MakeCtx: %s", err)
+		}
+		wrappers = append(wrappers, w)
+	}
+	shared, err := confection.MakeCtx[Greeter](ctx, c, tcs[2])
+	if err != nil {
+		t.Fatalf("MakeCtx: %s", err)
+	}
+
+	if n := built.Load(); n != 1 {
+		t.Errorf("expected the shared config built once, got %d", n)
+	}
+	if wrappers[0].Inner() != shared || wrappers[1].Inner() != shared {
+		t.Error("expected every reference to resolve to the same instance")
+	}
+	if got := wrappers[1].(*WrapperImpl).Greet(); got != "second: Howdy" {
+		t.Errorf("expected 'second: Howdy', got %q", got)
+	}
+	if x, ok := scope.Lookup("shared"); !ok || x != shared {
+		t.Errorf("expected Lookup to return the shared instance, got %v", x)
+	}
+}
+
+func TestScope_Cycle(t *testing.T) {
+	var built atomic.Int32
+	c := scopeRegistry(&built)
+
+	tcs := unmarshalConfigs(t, `
+- name: a
+  typed_config:
+    "@type": wrapper
+    child:
+      typed_config:
+        "@ref": b
+- name: b
+  typed_config:
+    "@type": wrapper
+    child:
+      name: c
+      typed_config:
+        "@type": wrapper
+        child:
+          typed_config:
+            "@ref": a
+`)
+	scope := confection.NewScope()
+	if err := scope.Declare(tcs...); err != nil {
+		t.Fatalf("Declare: %s", err)
+	}
+	ctx := confection.WithScope(context.Background(), scope)
+
+	_, err := confection.MakeCtx[Wrapper](ctx, c, tcs[0])
+	if !errors.Is(err, confection.ErrCycle) {
+		t.Fatalf("expected ErrCycle, got %v", err)
+	}
+	var cycle *confection.CycleError
+	if !errors.As(err, &cycle) || !slices.Equal(cycle.Path, []string{"a", "b", "c", "a"}) {
+		t.Errorf("expected the cycle path a -> b -> c -> a, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "dependency cycle: a -> b -> c -> a") {
+		t.Errorf("expected the path in the message, got %q", err)
+	}
+}
+
+func TestScope_CycleAcrossGoroutines(t *testing.T) {
+	var built atomic.Int32
+	c := scopeRegistry(&built)
+	// both builds are under way before either follows its reference
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	confection.RegisterFactory(c, "rendezvous", func(ctx context.Context, cfg *WrapperConfig) (*WrapperImpl, error) {
+		arrived.Done()
+		arrived.Wait()
+		inner, err := confection.MakeCtx[Greeter](ctx, c, cfg.Child)
+		if err != nil {
+			return nil, err
+		}
+		return &WrapperImpl{inner: inner}, nil
+	})
+
+	tcs := unmarshalConfigs(t, `
+- name: a
+  typed_config:
+    "@type": rendezvous
+    child:
+      typed_config:
+        "@ref": b
+- name: b
+  typed_config:
+    "@type": rendezvous
+    child:
+      typed_config:
+        "@ref": a
+`)
+	scope := confection.NewScope()
+	if err := scope.Declare(tcs...); err != nil {
+		t.Fatalf("Declare: %s", err)
+	}
+	ctx := confection.WithScope(context.Background(), scope)
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(tcs))
+	for i, tc := range tcs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = confection.MakeCtx[Wrapper](ctx, c, tc)
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if !errors.Is(err, confection.ErrCycle) {
+			t.Errorf("%s: expected ErrCycle, got %v", tcs[i].Name, err)
+		}
+	}
+}
+
+func TestScope_ConcurrentReferences(t *testing.T) {
+	var built atomic.Int32
+	c := scopeRegistry(&built)
+
+	tcs := unmarshalConfigs(t, `
+- name: shared
+  typed_config:
+    "@type": greetings.english
+`)
+	scope := confection.NewScope()
+	if err := scope.Declare(tcs...); err != nil {
+		t.Fatalf("Declare: %s", err)
+	}
+	ctx := confection.WithScope(context.Background(), scope)
+
+	var wg sync.WaitGroup
+	results := make([]Greeter, 16)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g, err := confection.MakeCtx[Greeter](ctx, c, confection.NewReference("shared"))
+			if err != nil {
+				t.Errorf("MakeCtx: %s", err)
+			}
+			results[i] = g
+		}()
+	}
+	wg.Wait()
+
+	if n := built.Load(); n != 1 {
+		t.Errorf("expected the shared config built once, got %d", n)
+	}
+	for _, g := range results {
+		if g != results[0] {
+			t.Fatal("expected every reference to resolve to the same instance")
+		}
+	}
+}
+
+func TestScope_Errors(t *testing.T) {
+	var built atomic.Int32
+	c := scopeRegistry(&built)
+	ref := confection.NewReference("missing")
+
+	if _, err := confection.Make[Greeter](c, ref); !errors.Is(err, confection.ErrUnknownReference) {
+		t.Errorf("expected ErrUnknownReference without a Scope, got %v", err)
+	}
+
+	scope := confection.NewScope()
+	ctx := confection.WithScope(context.Background(), scope)
+	_, err := confection.MakeCtx[Greeter](ctx, c, ref)
+	if !errors.Is(err, confection.ErrUnknownReference) {
+		t.Fatalf("expected ErrUnknownReference, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `@ref "missing"`) {
+		t.Errorf("expected the reference in the message, got %q", err)
+	}
+
+	tcs := unmarshalConfigs(t, `
+- name: dup
+  typed_config:
+    "@type": greetings.english
+- name: dup
+  typed_config:
+    "@type": greetings.english
+`)
+	if err := scope.Declare(tcs...); !errors.Is(err, confection.ErrDuplicateName) {
+		t.Errorf("expected ErrDuplicateName declaring, got %v", err)
+	}
+	if _, err := confection.MakeCtx[Greeter](ctx, c, tcs[1]); !errors.Is(err, confection.ErrDuplicateName) {
+		t.Errorf("expected ErrDuplicateName building, got %v", err)
+	}
+
+	// the instance must implement the referring Interface
+	if _, err := confection.MakeCtx[Greeter](ctx, c, tcs[0]); err != nil {
+		t.Fatalf("MakeCtx: %s", err)
+	}
+	if _, err := confection.MakeCtx[Wrapper](ctx, c, confection.NewReference("dup")); err == nil || !strings.Contains(err.Error(), "does not implement") {
+		t.Errorf("expected an Interface mismatch, got %v", err)
+	}
+}
+
+func TestTypedConfig_Reference(t *testing.T) {
+	input := `
+typed_config:
+  "@ref": upstream
+`
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	if tc.Ref() != "upstream" || tc.Type() != "" {
+		t.Errorf("expected a reference to upstream, got ref %q, type %q", tc.Ref(), tc.Type())
+	}
+	if got := marshalYAML(t, tc); got != strings.TrimPrefix(input, "\n") {
+		t.Errorf("expected the reference written back as is, got:\n%s", got)
+	}
+	if got := marshalYAML(t, confection.NewReference("upstream")); !strings.Contains(got, `"@ref": upstream`) {
+		t.Errorf("expected NewReference to marshal as a reference, got:\n%s", got)
+	}
+
+	for _, tt := range []struct {
+		input string
+		want  string
+	}{
+		{"typed_config: {\"@ref\": upstream, size: 3}", `@ref cannot be combined with "size"`},
+		{"typed_config: {\"@ref\": upstream, \"@type\": pool}", `@ref cannot be combined with "@type"`},
+		{"name: pool\ntyped_config: {\"@ref\": upstream}", "@ref cannot be named"},
+		{"typed_config: {\"@ref\": [upstream]}", "@ref must be a name"},
+	} {
+		err := yaml.Unmarshal([]byte(tt.input), &tc)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.input, tt.want, err)
+		}
+	}
+}
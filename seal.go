@@ -0,0 +1,68 @@
+package confection
+
+import (
+	"maps"
+	"slices"
+)
+
+// Seal makes every later registration with c fail with ErrSealed: the Try
+// functions return it and the others panic with it. Sealing after init
+// protects a production registry from late registrations by third-party
+// packages. Make and the other read paths are unaffected, as are children of
+// c created with WithParent. Sealing cannot be undone, but Clone returns an
+// unsealed copy.
+func (c *Confection) Seal() {
+	conf := getConfection(c)
+
+	conf.mu.Lock()
+	defer conf.mu.Unlock()
+
+	conf.sealed = true
+}
+
+// Sealed reports whether c has been sealed.
+func (c *Confection) Sealed() bool {
+	conf := getConfection(c)
+
+	conf.mu.RLock()
+	defer conf.mu.RUnlock()
+
+	return conf.sealed
+}
+
+// Clone returns an unsealed copy of c with the same options and registrations,
+// which can be changed without affecting c, such as a per-test copy of Global:
+//
+//	c := confection.Global.Clone()
+//	confection.RegisterFactory(c, "greetings.english", FakeEnglishFactory)
+//
+// A clone of a child registry shares its parent with the original.
+func (c *Confection) Clone() *Confection {
+	conf := getConfection(c)
+
+	conf.mu.RLock()
+	defer conf.mu.RUnlock()
+
+	clone := &Confection{
+		interfaces:   make(map[string]*_interface, len(conf.interfaces)),
+		conversions:  maps.Clone(conf.conversions),
+		aliases:      maps.Clone(conf.aliases),
+		deprecations: maps.Clone(conf.deprecations),
+		resolvers:    slices.Clone(conf.resolvers),
+		interceptors: slices.Clone(conf.interceptors),
+		parent:       conf.parent,
+		warn:         conf.warn,
+		strict:       conf.strict,
+		format:       conf.format,
+	}
+	for name, iface := range conf.interfaces {
+		clone.interfaces[name] = &_interface{
+			_type:           iface._type,
+			registeredTypes: maps.Clone(iface.registeredTypes),
+			resolvers:       slices.Clone(iface.resolvers),
+			decorators:      slices.Clone(iface.decorators),
+		}
+	}
+
+	return clone
+}
@@ -0,0 +1,124 @@
+package confection_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/raphaelreyna/confection"
+)
+
+func TestSeal_RejectsRegistration(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+	c.Seal()
+	if !c.Sealed() {
+		t.Fatal("expected registry to be sealed")
+	}
+
+	resolver := func(context.Context, *confection.Confection, string, string) error { return nil }
+	interceptor := func(ctx context.Context, call *confection.FactoryCall, next confection.Invoker) (any, error) {
+		return next(ctx, call)
+	}
+	for name, err := range map[string]error{
+		"interface":        confection.TryRegisterInterface[Wrapper](c),
+		"factory":          confection.TryRegisterFactory(c, "greetings.spanish", SpanishFactory),
+		"alias":            confection.TryRegisterAlias(c, "greetings.en", "greetings.english"),
+		"conversion":       confection.TryRegisterConversion(c, "greetings.english/v0", "greetings.english", SpanishV1ToV2),
+		"resolver":         confection.TryRegisterResolver[Greeter](c, resolver),
+		"deprecation":      confection.TryDeprecate(c, "greetings.english", confection.Deprecation{}),
+		"default resolver": confection.TryRegisterDefaultResolver(c, resolver),
+		"interceptor":      confection.TryRegisterInterceptor(c, interceptor),
+	} {
+		if !errors.Is(err, confection.ErrSealed) {
+			t.Errorf("%s: expected ErrSealed, got %v", name, err)
+		}
+	}
+
+	func() {
+		defer func() {
+			if err, _ := recover().(error); !errors.Is(err, confection.ErrSealed) {
+				t.Errorf("expected Deprecate to panic with ErrSealed, got %v", err)
+			}
+		}()
+		confection.Deprecate(c, "greetings.english", confection.Deprecation{})
+	}()
+
+	if got := greet(t, c, "greetings.english"); got != "Hello" {
+		t.Errorf("expected Make to work on a sealed registry, got %q", got)
+	}
+}
+
+func TestSeal_ResolverFails(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterResolver[Greeter](c, func(_ context.Context, c *confection.Confection, _, typeName string) error {
+		return confection.TryRegisterFactory(c, typeName, EnglishFactory)
+	})
+	c.Seal()
+
+	tc, err := confection.NewTypedConfig("", "greetings.english", nil)
+	if err != nil {
+		t.Fatalf("NewTypedConfig: %s", err)
+	}
+	if _, err := confection.Make[Greeter](c, tc); !errors.Is(err, confection.ErrSealed) {
+		t.Errorf("expected ErrSealed from the resolver, got %v", err)
+	}
+}
+
+func TestSeal_ChildUnaffected(t *testing.T) {
+	parent := confection.NewConfection()
+	confection.RegisterInterface[Greeter](parent)
+	parent.Seal()
+
+	child := confection.NewConfection(confection.WithParent(parent))
+	if err := confection.TryRegisterFactory(child, "greetings.english", EnglishFactory); err != nil {
+		t.Errorf("expected a child of a sealed registry to accept registrations, got %v", err)
+	}
+}
+
+func TestClone_IsIndependent(t *testing.T) {
+	c := confection.NewConfection(confection.WithStrictDecoding())
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+	confection.RegisterAlias(c, "greetings.en", "greetings.english")
+	c.Seal()
+
+	clone := c.Clone()
+	if clone.Sealed() {
+		t.Error("expected the clone to be unsealed")
+	}
+	if got := greet(t, clone, "greetings.en"); got != "Hello" {
+		t.Errorf("expected the clone to keep registrations, got %q", got)
+	}
+
+	confection.RegisterFactory(clone, "greetings.spanish", SpanishFactory)
+	confection.RegisterInterface[Wrapper](clone)
+	if _, ok := confection.LookupFactory[Greeter](c, "greetings.spanish"); ok {
+		t.Error("expected registrations with the clone to leave the original untouched")
+	}
+	if got := confection.Interfaces(c); len(got) != 1 {
+		t.Errorf("expected the original to keep one Interface, got %d", len(got))
+	}
+
+	tc, err := confection.NewTypedConfig("", "greetings.english", map[string]string{"bogus": "x"})
+	if err != nil {
+		t.Fatalf("NewTypedConfig: %s", err)
+	}
+	if _, err := confection.Make[Greeter](clone, tc); !errors.Is(err, confection.ErrUnknownField) {
+		t.Errorf("expected the clone to keep strict decoding, got %v", err)
+	}
+}
+
+func TestClone_Global(t *testing.T) {
+	global := confection.NewConfection()
+	confection.RegisterInterface[Greeter](global)
+	useGlobal(t, global)
+
+	clone := confection.Global.Clone()
+	confection.RegisterFactory(clone, "greetings.english", EnglishFactory)
+	if _, ok := confection.LookupFactory[Greeter](nil, "greetings.english"); ok {
+		t.Error("expected the global registry to be untouched")
+	}
+}
@@ -0,0 +1,112 @@
+package confection
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// checkKnownFields reports the first mapping key in n that does not match a
+// field of t, following the same rules as the yaml decoder. yaml.v3 only
+// supports this on a Decoder, so the check walks the node tree itself.
+func checkKnownFields(n *yaml.Node, t reflect.Type) *Error {
+	if n == nil {
+		return nil
+	}
+	if n.Kind == yaml.AliasNode {
+		return checkKnownFields(n.Alias, t)
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == _typedConfigType || t == _yamlNodeType {
+		return nil
+	}
+	if t.Implements(_yamlUnmarshaler) || reflect.PointerTo(t).Implements(_yamlUnmarshaler) {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if n.Kind != yaml.MappingNode {
+			return nil
+		}
+		fields, inlineMap := yamlFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i]
+			idx := slices.IndexFunc(fields, func(f yamlField) bool {
+				return f.Name == key.Value
+			})
+			if idx == -1 {
+				if inlineMap || key.Kind == yaml.AliasNode || key.Tag == "!!merge" {
+					continue
+				}
+				return &Error{
+					Line:   key.Line,
+					Column: key.Column,
+					Err:    fmt.Errorf("%w %q; valid fields: %s", ErrUnknownField, key.Value, fieldNames(fields)),
+				}
+			}
+			if err := checkKnownFields(n.Content[i+1], fields[idx].Type); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if n.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for _, item := range n.Content {
+			if err := checkKnownFields(item, t.Elem()); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if n.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if err := checkKnownFields(n.Content[i+1], t.Elem()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkConfigFields is checkKnownFields for the config block n read in format
+// f. The keys the shape leaves in the block are not reported unless t
+// declares them.
+func checkConfigFields(n *yaml.Node, t reflect.Type, f format) *Error {
+	keys := f.ownKeys()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if n == nil || n.Kind != yaml.MappingNode || t.Kind() != reflect.Struct || len(keys) == 0 {
+		return checkKnownFields(n, t)
+	}
+
+	fields, _ := yamlFields(t)
+	block := *n
+	block.Content = nil
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key := n.Content[i].Value
+		if slices.Contains(keys, key) && !slices.ContainsFunc(fields, func(f yamlField) bool { return f.Name == key }) {
+			continue
+		}
+		block.Content = append(block.Content, n.Content[i], n.Content[i+1])
+	}
+	return checkKnownFields(&block, t)
+}
+
+func fieldNames(fields []yamlField) string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.Name
+	}
+	slices.Sort(names)
+	return strings.Join(names, ", ")
+}
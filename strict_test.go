@@ -0,0 +1,144 @@
+package confection_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/raphaelreyna/confection"
+	"gopkg.in/yaml.v3"
+)
+
+func TestStrictDecoding_UnknownField(t *testing.T) {
+	c := confection.NewConfection(confection.WithStrictDecoding())
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.spanish", SpanishFactory)
+
+	input := `
+greeting:
+  name: spanish
+  typed_config:
+    "@type": greetings.spanish
+    use_fromal: true
+`
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(input), &root); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	var cfg struct {
+		Greeting confection.TypedConfig `yaml:"greeting"`
+	}
+	if err := root.Decode(&cfg); err != nil {
+		t.Fatalf("decode: %s", err)
+	}
+
+	_, err := confection.Make[Greeter](c, cfg.Greeting)
+	err = confection.ResolvePath(&root, err)
+	if !errors.Is(err, confection.ErrUnknownField) {
+		t.Fatalf("expected ErrUnknownField, got %v", err)
+	}
+
+	var e *confection.Error
+	if !errors.As(err, &e) {
+		t.Fatalf("expected *confection.Error, got %T", err)
+	}
+	if e.Line != 6 {
+		t.Errorf("expected line 6, got %d", e.Line)
+	}
+	if e.Type != "greetings.spanish" {
+		t.Errorf("expected @type in error, got %q", e.Type)
+	}
+	if e.Path != "greeting.typed_config.use_fromal" {
+		t.Errorf("unexpected path: %q", e.Path)
+	}
+	for _, want := range []string{`"use_fromal"`, "valid fields: use_formal"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got: %s", want, err)
+		}
+	}
+
+	if err := confection.Validate[Greeter](c, cfg.Greeting); !errors.Is(err, confection.ErrUnknownField) {
+		t.Errorf("expected Validate to report ErrUnknownField, got %v", err)
+	}
+}
+
+func TestStrictDecoding_NestedStruct(t *testing.T) {
+	type Inner struct {
+		Value string `yaml:"value"`
+	}
+	type Outer struct {
+		Inner []Inner           `yaml:"inner"`
+		Extra map[string]string `yaml:",inline"`
+	}
+	c := confection.NewConfection(confection.WithStrictDecoding())
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "nested", func(_ context.Context, cfg Outer) (*English, error) {
+		return &English{}, nil
+	})
+
+	input := `
+name: nested
+typed_config:
+  "@type": nested
+  anything: goes
+  inner:
+  - value: ok
+  - valeu: typo
+`
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+
+	err := confection.Validate[Greeter](c, tc)
+	if !errors.Is(err, confection.ErrUnknownField) {
+		t.Fatalf("expected ErrUnknownField, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `"valeu"`) {
+		t.Errorf("expected error to name the unknown key, got: %s", err)
+	}
+}
+
+func TestStrictDecoding_DisabledByDefault(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.spanish", SpanishFactory)
+
+	input := `
+name: spanish
+typed_config:
+  "@type": greetings.spanish
+  use_fromal: true
+`
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	if _, err := confection.Make[Greeter](c, tc); err != nil {
+		t.Fatalf("expected unknown keys to be ignored, got %s", err)
+	}
+}
+
+func TestStrictDecoding_FlatShapeName(t *testing.T) {
+	c := confection.NewConfection(confection.WithShape(confection.FlatShape), confection.WithStrictDecoding())
+	useGlobal(t, c)
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte("name: english\n\"@type\": greetings.english\ngreeting: Hi\n"), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	if _, err := confection.Make[Greeter](c, tc); err != nil {
+		t.Fatalf("expected the name to be allowed next to the config, got %s", err)
+	}
+
+	if err := yaml.Unmarshal([]byte("name: english\n\"@type\": greetings.english\ngreting: Hi\n"), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	_, err := confection.Make[Greeter](c, tc)
+	if !errors.Is(err, confection.ErrUnknownField) || !strings.Contains(err.Error(), `"greting"`) {
+		t.Errorf("expected ErrUnknownField for greting, got %v", err)
+	}
+}
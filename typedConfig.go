@@ -1,52 +1,14 @@
 package confection
 
 import (
+	"errors"
 	"fmt"
 	"slices"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
-type node struct {
-	node  *yaml.Node
-	_type string
-}
-
-func (n *node) UnmarshalYAML(value *yaml.Node) error {
-	n.node = &yaml.Node{
-		Kind:        value.Kind,
-		Style:       value.Style,
-		Tag:         value.Tag,
-		Value:       value.Value,
-		Anchor:      value.Anchor,
-		Alias:       value.Alias,
-		Line:        value.Line,
-		Column:      value.Column,
-		HeadComment: value.HeadComment,
-		LineComment: value.LineComment,
-		FootComment: value.FootComment,
-	}
-
-	typePosition := -1
-	for i, content := range value.Content {
-		if content.Value == "@type" {
-			typePosition = i
-			break
-		}
-	}
-	if typePosition == -1 {
-		return fmt.Errorf("line %d: @type not found in typed_config", value.Line)
-	}
-	if typePosition+1 >= len(value.Content) {
-		return fmt.Errorf("line %d: @type has no value in typed_config", value.Content[typePosition].Line)
-	}
-	n._type = value.Content[typePosition+1].Value
-
-	n.node.Content = slices.Concat(value.Content[:typePosition], value.Content[typePosition+2:])
-
-	return nil
-}
-
 // TypedConfig represents a named configuration block with a @type discriminator.
 // It is unmarshalled from YAML in the form:
 //
@@ -54,12 +16,75 @@ func (n *node) UnmarshalYAML(value *yaml.Node) error {
 //	typed_config:
 //	  "@type": some.type.name
 //	  key: value
+//
+// The discriminator key and layout can be changed with WithTypeKey and WithShape.
 type TypedConfig struct {
-	Name        string     `yaml:"name"`
+	Name string `yaml:"name"`
+	// DependsOn lists the names of the TypedConfigs Build must construct
+	// before this one, each of which must have a field in Build's result. It
+	// is read from a depends_on key next to name, except in KubernetesShape.
+	DependsOn   []string   `yaml:"depends_on"`
 	TypedConfig *yaml.Node `yaml:"typed_config"`
 	_type       string
 	line        int
 	column      int
+	json        bool
+
+	// raw is the mapping the TypedConfig was decoded from, and discriminators
+	// are the entries stripped from the config block, so that marshalling can
+	// restore both losslessly.
+	raw            *yaml.Node
+	discriminators []discriminator
+	format         format
+
+	// ref is the name the TypedConfig refers to, if it is a reference.
+	ref string
+}
+
+// discriminator is a key/value pair stripped from a config block, and its
+// index in the block's Content.
+type discriminator struct {
+	index int
+	key   *yaml.Node
+	value *yaml.Node
+}
+
+// NewTypedConfig builds a TypedConfig in Go code, for tests and code-driven
+// setups that call Make without writing YAML. cfg is encoded into the
+// typed_config block honouring its yaml tags and may be nil for an empty block.
+func NewTypedConfig(name, typeName string, cfg any) (TypedConfig, error) {
+	n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if cfg != nil {
+		if err := n.Encode(cfg); err != nil {
+			return TypedConfig{}, fmt.Errorf("unable to encode config for @type %q: %w", typeName, err)
+		}
+	}
+	if n.Kind == yaml.ScalarNode && n.ShortTag() == "!!null" {
+		n = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+	if n.Kind != yaml.MappingNode {
+		return TypedConfig{}, fmt.Errorf("unable to encode config for @type %q: %T does not encode to a mapping", typeName, cfg)
+	}
+
+	return TypedConfig{
+		Name:        name,
+		TypedConfig: n,
+		_type:       typeName,
+		format:      globalFormat(),
+	}, nil
+}
+
+// NewReference builds a TypedConfig referring to the instance built from the
+// TypedConfig named name in the same Scope.
+func NewReference(name string) TypedConfig {
+	return TypedConfig{
+		TypedConfig: &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: RefKey, Style: yaml.DoubleQuotedStyle},
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: name},
+		}},
+		ref:    name,
+		format: globalFormat(),
+	}
 }
 
 func (c *TypedConfig) String() string {
@@ -71,25 +96,359 @@ func (c *TypedConfig) Type() string {
 	return c._type
 }
 
+// Ref returns the name a reference refers to, or "" if c is not a reference.
+func (c *TypedConfig) Ref() string {
+	return c.ref
+}
+
+// GroupVersionKind returns the apiVersion and kind of a config read in
+// KubernetesShape. ok is false for configs in other shapes.
+func (c *TypedConfig) GroupVersionKind() (gvk GroupVersionKind, ok bool) {
+	if c.format.shape != KubernetesShape {
+		return GroupVersionKind{}, false
+	}
+	values := c.format.values(c._type)
+	return ParseGroupVersionKind(values[0], values[1]), true
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, using the format of the global registry.
 func (c *TypedConfig) UnmarshalYAML(value *yaml.Node) error {
-	type T struct {
-		Name        string `yaml:"name"`
-		TypedConfig *node  `yaml:"typed_config"`
+	return c.parse(value, globalFormat())
+}
+
+// parse reads a TypedConfig laid out in format f from value, stripping the
+// discriminator from the config block.
+func (c *TypedConfig) parse(value *yaml.Node, f format) error {
+	if value.Kind != yaml.MappingNode {
+		return &Error{Line: value.Line, Column: value.Column, Err: fmt.Errorf("expected a mapping, got %s", value.ShortTag())}
 	}
-	var t T
-	if err := value.Decode(&t); err != nil {
+
+	nameNode := mappingNode(value, "name")
+	if f.shape == KubernetesShape {
+		nameNode = nil
+		if metadata := mappingNode(value, "metadata"); metadata != nil {
+			nameNode = mappingNode(metadata, "name")
+		}
+	}
+	var name string
+	if nameNode != nil {
+		if err := nameNode.Decode(&name); err != nil {
+			return err
+		}
+	}
+
+	dependsOn, err := parseDependsOn(value, f, name)
+	if err != nil {
 		return err
 	}
 
-	if t.TypedConfig == nil {
-		return fmt.Errorf("line %d: typed_config is required", value.Line)
+	block := value
+	if f.shape == EnvelopeShape {
+		block = mappingNode(value, "typed_config")
+		if block == nil || block.ShortTag() == "!!null" {
+			return &Error{Line: value.Line, Column: value.Column, Name: name, Err: ErrMissingTypedConfig}
+		}
+	}
+
+	if ref := mappingNode(block, RefKey); ref != nil && block.Kind == yaml.MappingNode {
+		if dependsOn != nil {
+			return &Error{Line: value.Line, Column: value.Column, Err: fmt.Errorf("%s cannot have depends_on", RefKey)}
+		}
+		return c.parseRef(value, block, ref, name, f)
+	}
+
+	var (
+		discriminators []discriminator
+		values         []string
+		missing        []string
+	)
+	for _, key := range f.keys() {
+		idx := -1
+		if block.Kind == yaml.MappingNode {
+			idx = slices.IndexFunc(block.Content, func(n *yaml.Node) bool {
+				return n.Value == key
+			})
+			// only keys sit at even indices
+			for idx != -1 && idx%2 != 0 {
+				next := slices.IndexFunc(block.Content[idx+1:], func(n *yaml.Node) bool {
+					return n.Value == key
+				})
+				if next == -1 {
+					idx = -1
+				} else {
+					idx += 1 + next
+				}
+			}
+		}
+		if idx == -1 || idx+1 >= len(block.Content) {
+			missing = append(missing, key)
+			continue
+		}
+		discriminators = append(discriminators, discriminator{
+			index: idx,
+			key:   block.Content[idx],
+			value: block.Content[idx+1],
+		})
+		values = append(values, block.Content[idx+1].Value)
+	}
+	if len(missing) > 0 {
+		return &Error{Line: block.Line, Column: block.Column, Name: name, Err: missingTypeError{keys: missing, block: f.block()}}
+	}
+	// depends_on is not part of a flat config, but is restored with the
+	// discriminators
+	if f.shape == FlatShape {
+		for i := 0; i+1 < len(block.Content); i += 2 {
+			if block.Content[i].Value == "depends_on" {
+				discriminators = append(discriminators, discriminator{index: i, key: block.Content[i], value: block.Content[i+1]})
+				break
+			}
+		}
+	}
+
+	stripped := *block
+	stripped.Content = nil
+	for i := 0; i+1 < len(block.Content); i += 2 {
+		if !slices.ContainsFunc(discriminators, func(d discriminator) bool { return d.index == i }) {
+			stripped.Content = append(stripped.Content, block.Content[i], block.Content[i+1])
+		}
+	}
+	slices.SortFunc(discriminators, func(a, b discriminator) int {
+		return a.index - b.index
+	})
+
+	*c = TypedConfig{
+		Name:           name,
+		DependsOn:      dependsOn,
+		TypedConfig:    &stripped,
+		_type:          f.typeName(values),
+		line:           value.Line,
+		column:         value.Column,
+		raw:            value,
+		discriminators: discriminators,
+		format:         f,
+	}
+
+	return nil
+}
+
+// parseDependsOn reads the depends_on list next to the name in value.
+func parseDependsOn(value *yaml.Node, f format, name string) ([]string, error) {
+	if f.shape == KubernetesShape {
+		return nil, nil
+	}
+	n := mappingNode(value, "depends_on")
+	if n == nil {
+		return nil, nil
+	}
+	var names []string
+	if err := n.Decode(&names); err != nil {
+		return nil, &Error{Line: n.Line, Column: n.Column, Name: name, Err: errors.New("depends_on must be a list of names")}
+	}
+	return names, nil
+}
+
+// parseRef reads a reference from block, whose RefKey entry is ref.
+func (c *TypedConfig) parseRef(value, block, ref *yaml.Node, name string, f format) error {
+	if ref.Kind != yaml.ScalarNode || ref.Value == "" {
+		return &Error{Line: ref.Line, Column: ref.Column, Name: name, Err: fmt.Errorf("%s must be a name", RefKey)}
+	}
+	if name != "" {
+		return &Error{Line: value.Line, Column: value.Column, Name: name, Err: fmt.Errorf("%s cannot be named", RefKey)}
+	}
+	for i := 0; i+1 < len(block.Content); i += 2 {
+		switch key := block.Content[i]; {
+		case key.Value == RefKey,
+			f.shape == FlatShape && key.Value == "name",
+			f.shape == KubernetesShape && key.Value == "metadata":
+		default:
+			return &Error{Line: key.Line, Column: key.Column, Err: fmt.Errorf("%s cannot be combined with %q", RefKey, key.Value)}
+		}
+	}
+
+	*c = TypedConfig{
+		TypedConfig: block,
+		ref:         ref.Value,
+		line:        value.Line,
+		column:      value.Column,
+		raw:         value,
+		format:      f,
+	}
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler. The @type discriminator is
+// re-inserted into typed_config at its original position, and the comments
+// and any other keys of the decoded mapping are preserved, so that a parsed
+// config can be modified and written back out.
+func (c TypedConfig) MarshalYAML() (any, error) {
+	typedConfig := c.typedConfigNode()
+	if c.format.shape == FlatShape {
+		for i := 0; i+1 < len(typedConfig.Content); i += 2 {
+			if typedConfig.Content[i].Value == "name" {
+				name := *typedConfig.Content[i+1]
+				name.Value = c.Name
+				typedConfig.Content[i+1] = &name
+			}
+		}
+		typedConfig.Content = withDependsOn(typedConfig.Content, c.DependsOn)
+		return typedConfig, nil
+	}
+	if c.format.shape == KubernetesShape {
+		return c.kubernetesNode(typedConfig), nil
+	}
+	if c.raw == nil {
+		return &yaml.Node{
+			Kind: yaml.MappingNode,
+			Tag:  "!!map",
+			Content: withDependsOn([]*yaml.Node{
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: "name"},
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: c.Name},
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: "typed_config"},
+				typedConfig,
+			}, c.DependsOn),
+		}, nil
 	}
 
-	c.Name = t.Name
-	c._type = t.TypedConfig._type
-	c.TypedConfig = t.TypedConfig.node
-	c.line = value.Line
-	c.column = value.Column
+	out := *c.raw
+	out.Content = slices.Clone(c.raw.Content)
+	hasName := false
+	for i := 0; i+1 < len(out.Content); i += 2 {
+		switch out.Content[i].Value {
+		case "name":
+			name := *out.Content[i+1]
+			name.Value = c.Name
+			out.Content[i+1] = &name
+			hasName = true
+		case "typed_config":
+			out.Content[i+1] = typedConfig
+		}
+	}
+	if !hasName && c.Name != "" {
+		out.Content = append([]*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: "name"},
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: c.Name},
+		}, out.Content...)
+	}
+	out.Content = withDependsOn(out.Content, c.DependsOn)
 
+	return &out, nil
+}
+
+// withDependsOn returns content, the entries of a mapping, with depends_on
+// listing names, or without depends_on if names is empty. An entry that
+// already lists names is kept as it is.
+func withDependsOn(content []*yaml.Node, names []string) []*yaml.Node {
+	list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, name := range names {
+		list.Content = append(list.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name})
+	}
+	for i := 0; i+1 < len(content); i += 2 {
+		if content[i].Value != "depends_on" {
+			continue
+		}
+		var current []string
+		if err := content[i+1].Decode(&current); err == nil && slices.Equal(current, names) {
+			return content
+		}
+		if len(names) == 0 {
+			return slices.Delete(slices.Clone(content), i, i+2)
+		}
+		content = slices.Clone(content)
+		content[i+1] = list
+		return content
+	}
+	if len(names) == 0 {
+		return content
+	}
+	return append(slices.Clip(content), &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "depends_on"}, list)
+}
+
+// kubernetesNode sets metadata.name in doc, a KubernetesShape document with
+// its discriminators restored, adding metadata if there is none.
+func (c *TypedConfig) kubernetesNode(doc *yaml.Node) *yaml.Node {
+	doc.Content = slices.Clone(doc.Content)
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if doc.Content[i].Value != "metadata" || doc.Content[i+1].Kind != yaml.MappingNode {
+			continue
+		}
+		metadata := *doc.Content[i+1]
+		metadata.Content = slices.Clone(metadata.Content)
+		doc.Content[i+1] = &metadata
+		for j := 0; j+1 < len(metadata.Content); j += 2 {
+			if metadata.Content[j].Value == "name" {
+				name := *metadata.Content[j+1]
+				name.Value = c.Name
+				metadata.Content[j+1] = &name
+				return doc
+			}
+		}
+		if c.Name != "" {
+			metadata.Content = append([]*yaml.Node{
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: "name"},
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: c.Name},
+			}, metadata.Content...)
+		}
+		return doc
+	}
+	if c.Name != "" {
+		doc.Content = append(doc.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "metadata"},
+			&yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: "name"},
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: c.Name},
+			}},
+		)
+	}
+	return doc
+}
+
+// typedConfigNode returns the config block with the discriminator re-inserted
+// at its original position.
+func (c *TypedConfig) typedConfigNode() *yaml.Node {
+	n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if c.TypedConfig != nil {
+		copied := *c.TypedConfig
+		n = &copied
+	}
+	// references keep their block as written
+	if c.ref != "" {
+		return n
+	}
+
+	discriminators := c.discriminators
+	if len(discriminators) == 0 {
+		for i, key := range c.format.keys() {
+			k := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+			if strings.HasPrefix(key, "@") {
+				k.Style = yaml.DoubleQuotedStyle
+			}
+			discriminators = append(discriminators, discriminator{
+				index: 2 * i,
+				key:   k,
+				value: &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: c.format.values(c._type)[i]},
+			})
+		}
+	}
+	// discriminators are sorted by their original index, so inserting them
+	// in order restores every one of them to its place
+	for _, d := range discriminators {
+		idx := min(d.index, len(n.Content))
+		n.Content = slices.Concat(n.Content[:idx], []*yaml.Node{d.key, d.value}, n.Content[idx:])
+	}
+
+	return n
+}
+
+// mappingNode returns the value stored under key in mapping node n, following aliases.
+func mappingNode(n *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			value := n.Content[i+1]
+			if value.Kind == yaml.AliasNode {
+				value = value.Alias
+			}
+			return value
+		}
+	}
 	return nil
 }
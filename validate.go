@@ -0,0 +1,35 @@
+package confection
+
+import (
+	"context"
+	"reflect"
+)
+
+// Validate checks that tc can be used to construct interface I without
+// constructing it: the factory for tc's @type is resolved and the typed_config
+// block is decoded into the factory's Configuration type, converting it if tc's
+// @type is an older version, but the factory itself is never called.
+// Resolvers are consulted as they are by Make. References are not checked, as
+// what they refer to is only known to the Scope of a build.
+// Pass nil for c to use the global registry.
+// Errors are returned as *Error.
+func Validate[I Interface](c *Confection, tc TypedConfig) error {
+	conf := getConfection(c)
+	interfaceName := reflect.TypeFor[I]().String()
+
+	if err := tc.reformat(conf.format); err != nil {
+		return err
+	}
+	if tc.ref != "" {
+		return nil
+	}
+	res, err := conf.resolve(context.Background(), interfaceName, &tc)
+	if err != nil {
+		return err
+	}
+	if _, err := conf.decode(interfaceName, res, &tc); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package confection_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/raphaelreyna/confection"
+	"gopkg.in/yaml.v3"
+)
+
+func TestValidate_DoesNotCallFactory(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+
+	var calls int
+	confection.RegisterFactory(c, "greetings.english", func(ctx context.Context, cfg *EnglishConfig) (*English, error) {
+		calls++
+		return EnglishFactory(ctx, cfg)
+	})
+
+	input := `
+name: english
+typed_config:
+  "@type": greetings.english
+  greeting: Hi
+`
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+
+	if err := confection.Validate[Greeter](c, tc); err != nil {
+		t.Fatalf("Validate: %s", err)
+	}
+	if calls != 0 {
+		t.Errorf("expected factory not to be called, got %d calls", calls)
+	}
+}
+
+func TestValidate_DecodeError(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+	confection.RegisterFactory(c, "greetings.english", EnglishFactory)
+
+	input := `
+name: english
+typed_config:
+  "@type": greetings.english
+  greeting: [not, a, string]
+`
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+
+	err := confection.Validate[Greeter](c, tc)
+	var e *confection.Error
+	if !errors.As(err, &e) {
+		t.Fatalf("expected *confection.Error, got %v", err)
+	}
+	if e.Type != "greetings.english" {
+		t.Errorf("expected @type in error, got %+v", e)
+	}
+}
+
+func TestValidate_UnknownType(t *testing.T) {
+	c := confection.NewConfection()
+	confection.RegisterInterface[Greeter](c)
+
+	input := `
+name: french
+typed_config:
+  "@type": greetings.french
+`
+	var tc confection.TypedConfig
+	if err := yaml.Unmarshal([]byte(input), &tc); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+
+	if err := confection.Validate[Greeter](c, tc); !errors.Is(err, confection.ErrUnknownType) {
+		t.Fatalf("expected ErrUnknownType, got %v", err)
+	}
+}